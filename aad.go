@@ -0,0 +1,156 @@
+package cryptio
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+)
+
+// AADMatch selects how DecryptWithAAD compares the presented additional data
+// with the one the blob was encrypted with.
+type AADMatch int
+
+const (
+	AADMatchExact  AADMatch = iota // The presented AAD must be identical (default)
+	AADMatchPrefix                 // The presented AAD may be a parent context, e.g. "tenant/app" for "tenant/app/resource"
+)
+
+func (m AADMatch) String() string {
+	switch m {
+	case AADMatchExact:
+		return "Exact"
+	case AADMatchPrefix:
+		return "Prefix"
+	default:
+		return "Unknown"
+	}
+}
+
+// aadSeparator splits hierarchical contexts for AADMatchPrefix.
+const aadSeparator = '/'
+
+// EncryptWithAAD encrypts plaintext and binds it to the additional data aad,
+// which must be presented again to decrypt. With AADMatchPrefix, the full aad is
+// stored in plaintext in the blob (see EncryptWithHeader) so that decryption can
+// accept a parent context; both sides must use the same AADMatch.
+func (c *Client) EncryptWithAAD(plaintext, aad []byte) ([]byte, error) {
+	if c.aadMatch == AADMatchPrefix {
+		return c.EncryptWithHeader(plaintext, aad)
+	}
+	return c.seal(nil, plaintext, aad)
+}
+
+// DecryptWithAAD decrypts data produced by EncryptWithAAD, checking aad according
+// to the client's AADMatch policy.
+func (c *Client) DecryptWithAAD(data, aad []byte) ([]byte, error) {
+	if c.aadMatch != AADMatchPrefix {
+		return c.open(data, aad)
+	}
+	plaintext, bound, err := c.DecryptWithHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if !isContextPrefix(aad, bound) {
+		return nil, ErrAADMismatch
+	}
+	return plaintext, nil
+}
+
+// isContextPrefix reports whether parent is context itself or one of its
+// ancestors in a separator-delimited hierarchy. Only the lengths, which are not
+// secret, influence the running time: the bytes are compared in constant time.
+func isContextPrefix(parent, context []byte) bool {
+	if len(parent) > len(context) {
+		return false
+	}
+	equal := subtle.ConstantTimeCompare(parent, context[:len(parent)])
+	if len(parent) == len(context) {
+		return equal == 1
+	}
+	boundary := subtle.ConstantTimeByteEq(context[len(parent)], aadSeparator)
+	if len(parent) > 0 {
+		boundary |= subtle.ConstantTimeByteEq(parent[len(parent)-1], aadSeparator)
+	}
+	return equal&boundary == 1
+}
+
+// validateAADMatch checks m is a known policy.
+func validateAADMatch(m AADMatch) error {
+	if m != AADMatchExact && m != AADMatchPrefix {
+		return fmt.Errorf("%w: unknown AAD match policy %d", ErrInvalidParams, m)
+	}
+	return nil
+}
+
+// Seal is EncryptWithAAD, named after the cipher.AEAD method.
+func (c *Client) Seal(plaintext, aad []byte) ([]byte, error) {
+	return c.EncryptWithAAD(plaintext, aad)
+}
+
+// Open is DecryptWithAAD, named after the cipher.AEAD method.
+func (c *Client) Open(ciphertext, aad []byte) ([]byte, error) {
+	return c.DecryptWithAAD(ciphertext, aad)
+}
+
+// fileDigest returns the SHA-256 of everything read from r, bound as additional
+// data by EncryptWithFileDigest.
+func fileDigest(r io.Reader) ([]byte, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, fmt.Errorf("hash external data: %w", err)
+	}
+	return h.Sum([]byte("cryptio file digest ")), nil
+}
+
+// EncryptWithFileDigest encrypts plaintext bound to the SHA-256 of externalData,
+// e.g. a small encrypted manifest tied to a large asset stored in the clear.
+// Decrypting with DecryptWithFileDigest fails unless the same data is supplied.
+func (c *Client) EncryptWithFileDigest(plaintext []byte, externalData io.Reader) ([]byte, error) {
+	digest, err := fileDigest(externalData)
+	if err != nil {
+		return nil, err
+	}
+	return c.seal(nil, plaintext, digest)
+}
+
+// DecryptWithFileDigest decrypts data produced by EncryptWithFileDigest, reading
+// externalData in full to check it is unchanged.
+func (c *Client) DecryptWithFileDigest(data []byte, externalData io.Reader) ([]byte, error) {
+	digest, err := fileDigest(externalData)
+	if err != nil {
+		return nil, err
+	}
+	return c.open(data, digest)
+}
+
+// contextAAD canonically encodes ctx as additional data: the entry count, then
+// each key and value length-prefixed, in key order, so that no two maps encode
+// alike, unlike naive concatenation ("ab"+"c" and "a"+"bc").
+func contextAAD(ctx map[string]string) []byte {
+	aad := binary.BigEndian.AppendUint32([]byte("cryptio context "), uint32(len(ctx))) //nolint:gosec // map sizes fit
+	for _, k := range slices.Sorted(maps.Keys(ctx)) {
+		aad = binary.BigEndian.AppendUint32(aad, uint32(len(k))) //nolint:gosec // string sizes fit
+		aad = append(aad, k...)
+		aad = binary.BigEndian.AppendUint32(aad, uint32(len(ctx[k]))) //nolint:gosec // string sizes fit
+		aad = append(aad, ctx[k]...)
+	}
+	return aad
+}
+
+// EncryptWithContext encrypts plaintext bound to a structured context, e.g.
+// {"tenant": "42", "table": "users"}, canonically encoded as additional data.
+// The context is not stored: DecryptWithContext must be given the same entries,
+// in any map order.
+func (c *Client) EncryptWithContext(plaintext []byte, ctx map[string]string) ([]byte, error) {
+	return c.seal(nil, plaintext, contextAAD(ctx))
+}
+
+// DecryptWithContext decrypts data produced by EncryptWithContext, failing with
+// ErrDecryptFailed unless ctx holds exactly the entries it was encrypted with.
+func (c *Client) DecryptWithContext(data []byte, ctx map[string]string) ([]byte, error) {
+	return c.open(data, contextAAD(ctx))
+}
@@ -0,0 +1,185 @@
+package cryptio
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestEncryptDecryptWithAADExact(t *testing.T) {
+	client, err := NewWithLevelProfile("AADSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	data, err := client.EncryptWithAAD([]byte("tenant secret"), []byte("acme/billing/invoices"))
+	if err != nil {
+		t.Fatalf("EncryptWithAAD failed: %v", err)
+	}
+
+	plaintext, err := client.DecryptWithAAD(data, []byte("acme/billing/invoices"))
+	if err != nil {
+		t.Fatalf("DecryptWithAAD failed: %v", err)
+	}
+	if string(plaintext) != "tenant secret" {
+		t.Errorf("Expected %q, got %q", "tenant secret", plaintext)
+	}
+	for _, aad := range []string{"acme/billing", "acme/billing/invoices/2024", "other/billing/invoices", ""} {
+		if _, err := client.DecryptWithAAD(data, []byte(aad)); err == nil {
+			t.Errorf("Exact match should reject AAD %q, but did not", aad)
+		}
+	}
+}
+
+func TestEncryptDecryptWithAADPrefix(t *testing.T) {
+	client, err := NewWithLevelProfile("AADSecret", SecurityUltraFast, ProfileBalanced, WithAADMatch(AADMatchPrefix))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	data, err := client.EncryptWithAAD([]byte("tenant secret"), []byte("acme/billing/invoices"))
+	if err != nil {
+		t.Fatalf("EncryptWithAAD failed: %v", err)
+	}
+
+	for _, aad := range []string{"acme/billing/invoices", "acme/billing", "acme/billing/", "acme"} {
+		if _, err := client.DecryptWithAAD(data, []byte(aad)); err != nil {
+			t.Errorf("Prefix match should accept AAD %q, got %v", aad, err)
+		}
+	}
+	for _, aad := range []string{"acme/bill", "acme/billing/invoices/2024", "other"} {
+		_, err := client.DecryptWithAAD(data, []byte(aad))
+		if !errors.Is(err, ErrAADMismatch) {
+			t.Errorf("Prefix match should reject AAD %q with ErrAADMismatch, got %v", aad, err)
+		}
+	}
+
+	if _, err := NewWithLevelProfile("AADSecret", SecurityUltraFast, ProfileBalanced, WithAADMatch(AADMatch(7))); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Expected ErrInvalidParams for an unknown policy, got %v", err)
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	client, err := NewWithLevelProfile("SealSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	for _, aad := range [][]byte{nil, []byte("context")} {
+		ciphertext, err := client.Seal([]byte("this is a secret message"), aad)
+		if err != nil {
+			t.Fatalf("Seal failed: %v", err)
+		}
+		plaintext, err := client.Open(ciphertext, aad)
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		if string(plaintext) != "this is a secret message" {
+			t.Errorf("Expected %q, got %q", "this is a secret message", plaintext)
+		}
+		if _, err := client.Open(ciphertext, []byte("other")); err == nil {
+			t.Error("Open with a different AAD should fail, but did not")
+		}
+	}
+
+	// Seal without AAD produces regular EncryptRaw blobs.
+	ciphertext, err := client.Seal([]byte("raw"), nil)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	if plaintext, err := client.DecryptRaw(ciphertext); err != nil || string(plaintext) != "raw" {
+		t.Errorf("DecryptRaw of a Seal blob failed: %q, %v", plaintext, err)
+	}
+}
+
+func TestEncryptWithFileDigest(t *testing.T) {
+	client, err := NewWithLevelProfile("ManifestSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	asset := bytes.Repeat([]byte("large plaintext asset "), 4096)
+	manifest, err := client.EncryptWithFileDigest([]byte(`{"version":"1.4.2"}`), bytes.NewReader(asset))
+	if err != nil {
+		t.Fatalf("EncryptWithFileDigest failed: %v", err)
+	}
+
+	plaintext, err := client.DecryptWithFileDigest(manifest, bytes.NewReader(asset))
+	if err != nil {
+		t.Fatalf("DecryptWithFileDigest failed: %v", err)
+	}
+	if string(plaintext) != `{"version":"1.4.2"}` {
+		t.Errorf("Unexpected manifest %q", plaintext)
+	}
+
+	modified := bytes.Clone(asset)
+	modified[len(modified)/2] ^= 0x01
+	if _, err := client.DecryptWithFileDigest(manifest, bytes.NewReader(modified)); err == nil {
+		t.Error("DecryptWithFileDigest should fail when the external data changed, but did not")
+	}
+	if _, err := client.DecryptRaw(manifest); err == nil {
+		t.Error("DecryptRaw without the external data should fail, but did not")
+	}
+}
+
+func TestDefaultAAD(t *testing.T) {
+	v1, err := NewWithLevelProfile("DefaultAADSecret", SecurityUltraFast, ProfileBalanced, WithDefaultAAD([]byte("app v1")))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	v2, err := NewWithLevelProfile("DefaultAADSecret", SecurityUltraFast, ProfileBalanced, WithDefaultAAD([]byte("app v2")))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	encrypted, err := v1.Encrypt("bound to v1")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if decrypted, err := v1.Decrypt(encrypted); err != nil || decrypted != "bound to v1" {
+		t.Errorf("Decrypt with the same default AAD failed: %q, %v", decrypted, err)
+	}
+	if _, err := v2.Decrypt(encrypted); err == nil {
+		t.Error("Decrypt with another default AAD should fail, but did not")
+	}
+
+	// The explicit variants override the default.
+	blob, err := v1.EncryptWithAAD([]byte("explicit"), []byte("request 7"))
+	if err != nil {
+		t.Fatalf("EncryptWithAAD failed: %v", err)
+	}
+	if plaintext, err := v2.DecryptWithAAD(blob, []byte("request 7")); err != nil || string(plaintext) != "explicit" {
+		t.Errorf("DecryptWithAAD should ignore the default AAD, got %q, %v", plaintext, err)
+	}
+	if _, err := v1.DecryptRaw(blob); err == nil {
+		t.Error("DecryptRaw should not accept a blob sealed with explicit AAD, but did")
+	}
+}
+
+func TestEncryptWithContext(t *testing.T) {
+	client, err := NewWithLevelProfile("ContextSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	ctx := map[string]string{"tenant": "ab", "table": "c"}
+	data, err := client.EncryptWithContext([]byte("row"), ctx)
+	if err != nil {
+		t.Fatalf("EncryptWithContext failed: %v", err)
+	}
+	plaintext, err := client.DecryptWithContext(data, map[string]string{"table": "c", "tenant": "ab"})
+	if err != nil || string(plaintext) != "row" {
+		t.Fatalf("DecryptWithContext = %q, %v; want \"row\"", plaintext, err)
+	}
+
+	// Each of these concatenates to the same bytes as ctx in one naive encoding or another.
+	for _, other := range []map[string]string{
+		{"tenant": "a", "table": "bc"},
+		{"tenant": "abc", "table": ""},
+		{"tablec": "", "tenant": "ab"},
+		{"tenant": "ab", "table": "c", "": ""},
+		{"tenant": "ab"},
+		nil,
+	} {
+		if _, err := client.DecryptWithContext(data, other); !errors.Is(err, ErrDecryptFailed) {
+			t.Errorf("Context %v: expected ErrDecryptFailed, got %v", other, err)
+		}
+	}
+	if _, err := client.DecryptWithAAD(data, []byte("tenantabtablec")); err == nil {
+		t.Error("DecryptWithAAD with the naive concatenation should fail, but did not")
+	}
+}
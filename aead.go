@@ -0,0 +1,174 @@
+package cryptio
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"fmt"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Cipher identifies the AEAD used to encrypt blobs. It is recorded in the blob
+// header, so a client decrypts blobs of any cipher whatever its own setting.
+type Cipher byte
+
+const (
+	CipherAESGCM            Cipher = iota // AES-GCM (default)
+	CipherChaCha20Poly1305                // ChaCha20-Poly1305 (RFC 8439), 12-byte nonce
+	CipherXChaCha20Poly1305               // XChaCha20-Poly1305, 24-byte nonce safe to pick at random
+)
+
+func (ci Cipher) String() string {
+	switch ci {
+	case CipherAESGCM:
+		return "AES-GCM"
+	case CipherChaCha20Poly1305:
+		return "ChaCha20-Poly1305"
+	case CipherXChaCha20Poly1305:
+		return "XChaCha20-Poly1305"
+	default:
+		return "Unknown"
+	}
+}
+
+// nonceSize returns the nonce size the cipher requires, or 0 when it is configurable.
+func (ci Cipher) nonceSize() int {
+	switch ci {
+	case CipherChaCha20Poly1305:
+		return chacha20poly1305.NonceSize
+	case CipherXChaCha20Poly1305:
+		return chacha20poly1305.NonceSizeX
+	default:
+		return 0
+	}
+}
+
+// validateCipher checks the header describes an AEAD that can actually be built.
+func (h header) validateCipher() error {
+	switch h.cipher {
+	case CipherAESGCM:
+		if h.flags&flagSubkey != 0 {
+			if h.params.NonceSize != subkeyNonceSize {
+				return fmt.Errorf("%w: per-message subkeys require a %d-byte nonce", ErrInvalidParams, subkeyNonceSize)
+			}
+			return validateGCMSizes(gcmNonceSize, h.tagSize)
+		}
+		return validateGCMSizes(h.params.NonceSize, h.tagSize)
+	case CipherChaCha20Poly1305, CipherXChaCha20Poly1305:
+		if h.flags&flagSubkey != 0 {
+			return fmt.Errorf("%w: per-message subkeys require AES-GCM", ErrInvalidParams)
+		}
+		if h.params.KeySize != chacha20poly1305.KeySize {
+			return fmt.Errorf("%w: %v requires a %d-byte key", ErrInvalidParams, h.cipher, chacha20poly1305.KeySize)
+		}
+		if h.params.NonceSize != h.cipher.nonceSize() {
+			return fmt.Errorf("%w: %v requires a %d-byte nonce", ErrInvalidParams, h.cipher, h.cipher.nonceSize())
+		}
+		if h.tagSize != chacha20poly1305.Overhead {
+			return fmt.Errorf("%w: %v requires a %d-byte tag", ErrInvalidParams, h.cipher, chacha20poly1305.Overhead)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown cipher %d", ErrInvalidParams, h.cipher)
+	}
+}
+
+// newAEAD derives the key for salt and returns the AEAD described by h.
+func (c *Client) newAEAD(salt []byte, h header) (cipher.AEAD, error) {
+	key, err := c.deriveKey(salt, h.params)
+	if err != nil {
+		return nil, err
+	}
+	return aeadFromKey(key, h)
+}
+
+// messageAEAD returns the AEAD sealing the message with the given salt and stored
+// nonce, and the nonce to seal it with. With flagSubkey, the AEAD is keyed with
+// HKDF(key, nonce) and used with a fixed nonce, since every key seals one message.
+func (c *Client) messageAEAD(salt, nonce []byte, h header) (cipher.AEAD, []byte, error) {
+	if h.flags&flagSubkey == 0 {
+		aead, err := c.newAEAD(salt, h)
+		return aead, nonce, err
+	}
+	key, err := c.deriveKey(salt, h.params)
+	if err != nil {
+		return nil, nil, err
+	}
+	subkey, err := hkdf.Key(sha256.New, key, nonce, "cryptio message key", int(h.params.KeySize))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrKeyDerivation, err)
+	}
+	aead, err := aeadFromKey(subkey, h)
+	return aead, make([]byte, gcmNonceSize), err
+}
+
+// aeadFromKey returns the AEAD described by h under key.
+func aeadFromKey(key []byte, h header) (cipher.AEAD, error) {
+	switch h.cipher {
+	case CipherChaCha20Poly1305:
+		return chacha20poly1305.New(key)
+	case CipherXChaCha20Poly1305:
+		return chacha20poly1305.NewX(key)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot build AES cipher from a %d-byte key: %v", ErrKeyDerivation, len(key), err)
+	}
+	if h.params.NonceSize != gcmNonceSize && h.flags&flagSubkey == 0 {
+		return cipher.NewGCMWithNonceSize(block, h.params.NonceSize)
+	}
+	return cipher.NewGCMWithTagSize(block, h.tagSize)
+}
+
+// MigrateCipher decrypts data, whatever cipher its header records, and encrypts
+// the plaintext again with newCipher. The Argon2 parameters recorded in data are
+// kept; the nonce and tag sizes become the defaults of newCipher. A namespace
+// client seals under its salt, with its size and never a derived nonce.
+// Headerless legacy blobs are opened like DecryptRaw does, and the new blob
+// records the parameters that opened them.
+func (c *Client) MigrateCipher(data []byte, newCipher Cipher) ([]byte, error) {
+	var plaintext []byte
+	var h header
+	var err error
+	if isLegacyBlob(data) {
+		plaintext, h, err = c.openLegacy(data, false)
+	} else {
+		plaintext, h, err = c.openHeader(nil, data, c.defaultAAD)
+	}
+	if err != nil {
+		return nil, err
+	}
+	h.flags &^= flagPadded | flagCompressed | flagChecksum // plaintext was unpadded, decompressed and checked by openHeader
+	if c.fixedSalt != nil {
+		// The new blob is sealed under the namespace salt, whose size may differ
+		// from data's and which would repeat a derived nonce.
+		h.params.SaltSize = len(c.fixedSalt)
+		h.flags &^= flagDerivedNonce
+	}
+	if newCipher != h.cipher {
+		h.flags &^= flagSubkey
+		h.cipher = newCipher
+		h.params.NonceSize = newCipher.nonceSize()
+		if h.params.NonceSize == 0 {
+			h.params.NonceSize = gcmNonceSize
+		}
+		h.tagSize = chacha20poly1305.Overhead
+	}
+	if err := h.validateCipher(); err != nil {
+		return nil, err
+	}
+	return c.sealHeader(nil, plaintext, c.defaultAAD, h)
+}
+
+// AEAD returns a cipher.AEAD keyed by Argon2id over the passphrase and salt, using
+// the client's cipher and parameters, for code that manages its own nonces and
+// framing. The same salt always yields the same key; it must be at least 16 bytes
+// and is not stored anywhere by cryptio. Never reuse a nonce with the same salt.
+func (c *Client) AEAD(salt []byte) (cipher.AEAD, error) {
+	if len(salt) < minSaltSize {
+		return nil, fmt.Errorf("%w: salt size %d is below the minimum of %d bytes", ErrInvalidParams, len(salt), minSaltSize)
+	}
+	return c.newAEAD(salt, c.newHeader())
+}
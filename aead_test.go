@@ -0,0 +1,132 @@
+package cryptio
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestEncryptDecryptCiphers(t *testing.T) {
+	plaintext := []byte("cipher agnostic secret")
+	for _, ci := range []Cipher{CipherAESGCM, CipherChaCha20Poly1305, CipherXChaCha20Poly1305} {
+		client, err := NewWithLevelProfile("CipherSecret", SecurityUltraFast, ProfileBalanced, WithCipher(ci))
+		if err != nil {
+			t.Fatalf("%v: failed to create client: %v", ci, err)
+		}
+		data, err := client.EncryptRaw(plaintext)
+		if err != nil {
+			t.Fatalf("%v: EncryptRaw failed: %v", ci, err)
+		}
+		if len(data) != client.CiphertextLen(len(plaintext)) {
+			t.Errorf("%v: expected %d bytes, got %d", ci, client.CiphertextLen(len(plaintext)), len(data))
+		}
+
+		// Decryption follows the header, not the decrypting client's cipher.
+		other, err := NewWithLevelProfile("CipherSecret", SecurityUltraFast, ProfileBalanced)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		decrypted, info, err := other.DecryptRawWithInfo(data)
+		if err != nil {
+			t.Fatalf("%v: DecryptRawWithInfo failed: %v", ci, err)
+		}
+		if !bytes.Equal(decrypted, plaintext) || info.Cipher != ci {
+			t.Errorf("%v: unexpected round-trip result %q, %+v", ci, decrypted, info)
+		}
+	}
+}
+
+func TestWithCipherInvalid(t *testing.T) {
+	if _, err := NewWithLevelProfile("CipherSecret", SecurityUltraFast, ProfileBalanced, WithCipher(Cipher(9))); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Expected ErrInvalidParams for an unknown cipher, got %v", err)
+	}
+	_, err := NewWithLevelProfile("CipherSecret", SecurityUltraFast, ProfileBalanced, WithCipher(CipherChaCha20Poly1305), WithNonceSize(16))
+	if !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Expected ErrInvalidParams for ChaCha20 with a 16-byte nonce, got %v", err)
+	}
+	_, err = NewWithLevelProfile("CipherSecret", SecurityUltraFast, ProfileBalanced, WithCipher(CipherXChaCha20Poly1305), WithTagSize(12))
+	if !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Expected ErrInvalidParams for XChaCha20 with a 12-byte tag, got %v", err)
+	}
+}
+
+func TestMigrateCipher(t *testing.T) {
+	client, err := NewWithLevelProfile("MigrateSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	plaintext := []byte("fleet-wide upgrade")
+	data, err := client.EncryptRaw(plaintext)
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+
+	migrated, err := client.MigrateCipher(data, CipherChaCha20Poly1305)
+	if err != nil {
+		t.Fatalf("MigrateCipher failed: %v", err)
+	}
+	decrypted, info, err := client.DecryptRawWithInfo(migrated)
+	if err != nil {
+		t.Fatalf("DecryptRawWithInfo after migration failed: %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("Expected %q, got %q", plaintext, decrypted)
+	}
+	if info.Cipher != CipherChaCha20Poly1305 || info.Params.ArgonMem != client.params.ArgonMem {
+		t.Errorf("Unexpected migrated blob info: %+v", info)
+	}
+
+	if _, err := client.MigrateCipher(migrated[:len(migrated)-1], CipherAESGCM); err == nil {
+		t.Error("MigrateCipher should fail on a tampered blob, but did not")
+	}
+}
+
+func TestClientAEAD(t *testing.T) {
+	salt := bytes.Repeat([]byte{0x17}, 16)
+	for _, ci := range []Cipher{CipherAESGCM, CipherXChaCha20Poly1305} {
+		client, err := NewWithLevelProfile("AEADSecret", SecurityUltraFast, ProfileBalanced, WithCipher(ci))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		aead, err := client.AEAD(salt)
+		if err != nil {
+			t.Fatalf("%v: AEAD failed: %v", ci, err)
+		}
+		nonce := make([]byte, aead.NonceSize())
+		nonce[len(nonce)-1] = 1
+		sealed := aead.Seal(nil, nonce, []byte("managed nonce"), []byte("ad"))
+		if len(sealed) != len("managed nonce")+aead.Overhead() {
+			t.Errorf("%v: unexpected sealed length %d", ci, len(sealed))
+		}
+
+		again, err := client.AEAD(salt)
+		if err != nil {
+			t.Fatalf("%v: AEAD failed: %v", ci, err)
+		}
+		plaintext, err := again.Open(nil, nonce, sealed, []byte("ad"))
+		if err != nil {
+			t.Fatalf("%v: Open failed: %v", ci, err)
+		}
+		if string(plaintext) != "managed nonce" {
+			t.Errorf("%v: expected %q, got %q", ci, "managed nonce", plaintext)
+		}
+	}
+
+	client, err := NewWithLevelProfile("AEADSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := client.AEAD(salt[:8]); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Expected ErrInvalidParams for a short salt, got %v", err)
+	}
+}
+
+func TestHasAESAcceleration(t *testing.T) {
+	first := HasAESAcceleration()
+	for range 3 {
+		if HasAESAcceleration() != first {
+			t.Fatal("HasAESAcceleration should be stable across calls")
+		}
+	}
+	t.Logf("AES acceleration: %v", first)
+}
@@ -0,0 +1,72 @@
+package cryptio
+
+import (
+	"runtime"
+	"sync"
+)
+
+// x/crypto/argon2 allocates its memory blocks on every call and offers no way to
+// reuse them. Batches therefore bound how many derivations run at once: peak memory
+// stays at workers × ArgonMem, and blocks freed by one item are recycled by the Go
+// allocator for the next instead of piling up.
+
+// EncryptRawBatch encrypts every plaintext with EncryptRaw using at most workers
+// concurrent key derivations (GOMAXPROCS when workers <= 0).
+// Results are in input order; the first error aborts the remaining items.
+func (c *Client) EncryptRawBatch(plaintexts [][]byte, workers int) ([][]byte, error) {
+	return runBatch(plaintexts, workers, c.EncryptRaw)
+}
+
+// DecryptRawBatch decrypts every blob with DecryptRaw, like EncryptRawBatch.
+func (c *Client) DecryptRawBatch(blobs [][]byte, workers int) ([][]byte, error) {
+	return runBatch(blobs, workers, c.DecryptRaw)
+}
+
+// runBatch applies fn to every input with a bounded worker pool.
+func runBatch(inputs [][]byte, workers int, fn func([]byte) ([]byte, error)) ([][]byte, error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(inputs))
+
+	results := make([][]byte, len(inputs))
+	jobs := make(chan int)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				out, err := fn(inputs[i])
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					continue
+				}
+				results[i] = out
+			}
+		}()
+	}
+	for i := range inputs {
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
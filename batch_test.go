@@ -0,0 +1,69 @@
+package cryptio
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestEncryptDecryptRawBatch(t *testing.T) {
+	client, err := NewWithLevelProfile("BatchSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	plaintexts := make([][]byte, 6)
+	for i := range plaintexts {
+		plaintexts[i] = []byte(fmt.Sprintf("record %d", i))
+	}
+
+	blobs, err := client.EncryptRawBatch(plaintexts, 2)
+	if err != nil {
+		t.Fatalf("EncryptRawBatch failed: %v", err)
+	}
+	decrypted, err := client.DecryptRawBatch(blobs, 0)
+	if err != nil {
+		t.Fatalf("DecryptRawBatch failed: %v", err)
+	}
+	for i := range plaintexts {
+		if !bytes.Equal(plaintexts[i], decrypted[i]) {
+			t.Errorf("Item %d: expected %q, got %q", i, plaintexts[i], decrypted[i])
+		}
+	}
+
+	blobs[3] = []byte("garbage")
+	if _, err := client.DecryptRawBatch(blobs, 2); err == nil {
+		t.Error("DecryptRawBatch should fail on an invalid blob, but did not")
+	}
+}
+
+func BenchmarkBatchDerive(b *testing.B) {
+	client, err := NewWithLevelProfile("BenchSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		b.Fatalf("Failed to create client: %v", err)
+	}
+	plaintexts := make([][]byte, 16)
+	for i := range plaintexts {
+		plaintexts[i] = []byte("this is a secret message for benchmark")
+	}
+
+	b.Run("Naive", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, p := range plaintexts {
+				if _, err := client.EncryptRaw(p); err != nil {
+					b.Fatalf("EncryptRaw failed: %v", err)
+				}
+			}
+		}
+	})
+	for _, workers := range []int{1, 4} {
+		b.Run(fmt.Sprintf("Batch-%d", workers), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := client.EncryptRawBatch(plaintexts, workers); err != nil {
+					b.Fatalf("EncryptRawBatch failed: %v", err)
+				}
+			}
+		})
+	}
+}
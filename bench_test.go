@@ -4,23 +4,11 @@ import (
 	"testing"
 )
 
-// All security levels
-var allSecurityLevels = []SecurityLevel{
-	SecurityUltraFast,
-	SecurityStandard,
-	SecurityMedium,
-	SecurityHigh,
-	SecurityExtreme,
-}
-
-// All profiles
-var allProfiles = []Argon2Profile{
-	ProfileRAMHeavy,
-	ProfileBalanced,
-	ProfileTradeoff,
-	ProfileCPUFavor,
-	ProfileCPUHeavy,
-}
+// All security levels and profiles
+var (
+	allSecurityLevels = AllSecurityLevels()
+	allProfiles       = AllProfiles()
+)
 
 // Human-friendly name for a combination
 func benchName(level SecurityLevel, profile Argon2Profile) string {
@@ -34,7 +22,7 @@ func BenchmarkEncryptDecrypt_AllCombinations(b *testing.B) {
 		for _, profile := range allProfiles {
 			name := benchName(security, profile)
 			b.Run(name, func(b *testing.B) {
-				client, err := New("BenchSecret", security, profile)
+				client, err := NewWithLevelProfile("BenchSecret", security, profile)
 				if err != nil {
 					b.Fatalf("Failed to create client: %v", err)
 				}
@@ -62,3 +50,90 @@ func BenchmarkEncryptDecrypt_AllCombinations(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkEncryptRawInto(b *testing.B) {
+	client, err := NewWithLevelProfile("BenchSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		b.Fatalf("Failed to create client: %v", err)
+	}
+	plaintext := []byte("this is a secret message for benchmark")
+
+	b.Run("EncryptRaw", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := client.EncryptRaw(plaintext); err != nil {
+				b.Fatalf("EncryptRaw failed: %v", err)
+			}
+		}
+	})
+	b.Run("PreSized", func(b *testing.B) {
+		b.ReportAllocs()
+		dst := make([]byte, 0, client.CiphertextLen(len(plaintext)))
+		for i := 0; i < b.N; i++ {
+			if _, err := client.EncryptRawInto(dst[:0], plaintext); err != nil {
+				b.Fatalf("EncryptRawInto failed: %v", err)
+			}
+		}
+	})
+}
+
+func BenchmarkDecryptRawInto(b *testing.B) {
+	// A namespace client caches its key, leaving only the decryption itself.
+	client, err := NewNamespace("BenchSecret", []byte("benchmark namespace salt"), SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		b.Fatalf("Failed to create client: %v", err)
+	}
+	plaintext := []byte("this is a secret message for benchmark")
+	blob, err := client.EncryptRaw(plaintext)
+	if err != nil {
+		b.Fatalf("EncryptRaw failed: %v", err)
+	}
+
+	b.Run("DecryptRaw", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := client.DecryptRaw(blob); err != nil {
+				b.Fatalf("DecryptRaw failed: %v", err)
+			}
+		}
+	})
+	b.Run("PreSized", func(b *testing.B) {
+		b.ReportAllocs()
+		dst := make([]byte, 0, len(plaintext))
+		for i := 0; i < b.N; i++ {
+			if _, err := client.DecryptRawInto(dst, blob); err != nil {
+				b.Fatalf("DecryptRawInto failed: %v", err)
+			}
+		}
+	})
+}
+
+func BenchmarkTokenEncryptor(b *testing.B) {
+	client, err := NewNamespace("BenchSecret", []byte("benchmark namespace salt"), SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		b.Fatalf("Failed to create client: %v", err)
+	}
+	te, err := NewTokenEncryptor(client, 64)
+	if err != nil {
+		b.Fatalf("NewTokenEncryptor failed: %v", err)
+	}
+	token := make([]byte, 48)
+
+	b.Run("EncryptRawInto", func(b *testing.B) {
+		b.ReportAllocs()
+		dst := make([]byte, 0, client.CiphertextLen(len(token)))
+		for i := 0; i < b.N; i++ {
+			if _, err := te.EncryptRawInto(dst, token); err != nil {
+				b.Fatalf("EncryptRawInto failed: %v", err)
+			}
+		}
+	})
+	b.Run("EncryptToken", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := te.EncryptToken(token); err != nil {
+				b.Fatalf("EncryptToken failed: %v", err)
+			}
+		}
+	})
+}
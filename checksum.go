@@ -0,0 +1,88 @@
+package cryptio
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+// Checksummed plaintexts are data | CRC-32C of data (uint32 BE), appended before
+// compression and padding, so the checksum is encrypted and authenticated too.
+
+const checksumSize = 4
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Diagnosis classifies the outcome of DecryptDiagnostic.
+type Diagnosis int
+
+const (
+	DiagnosisOK               Diagnosis = iota // Decrypted, and the checksum matched if the blob has one
+	DiagnosisMalformed                         // Header or lengths invalid: truncated or corrupted framing, whatever the key
+	DiagnosisAuthFailed                        // Well-formed but not authentic: wrong passphrase or additional data, or a corrupted body
+	DiagnosisChecksumMismatch                  // Authentic, but the plaintext checksum does not match: the encrypting side was faulty
+	DiagnosisOther                             // Failed for another reason, e.g. WithRejectBelowPolicy; see the error
+)
+
+func (d Diagnosis) String() string {
+	switch d {
+	case DiagnosisOK:
+		return "OK"
+	case DiagnosisMalformed:
+		return "Malformed"
+	case DiagnosisAuthFailed:
+		return "AuthFailed"
+	case DiagnosisChecksumMismatch:
+		return "ChecksumMismatch"
+	case DiagnosisOther:
+		return "Other"
+	default:
+		return "Unknown"
+	}
+}
+
+// addChecksum appends the CRC-32C of plaintext when WithPlaintextChecksum is set,
+// and flags h accordingly. The caller's slice is not modified.
+func (c *Client) addChecksum(h *header, plaintext []byte) []byte {
+	if !c.checksum {
+		return plaintext
+	}
+	h.flags |= flagChecksum
+	out := make([]byte, len(plaintext), len(plaintext)+checksumSize)
+	copy(out, plaintext)
+	return binary.BigEndian.AppendUint32(out, crc32.Checksum(plaintext, castagnoli))
+}
+
+// verifyChecksum strips and checks the checksum added by addChecksum.
+func verifyChecksum(data []byte) ([]byte, error) {
+	if len(data) < checksumSize {
+		return nil, ErrInvalidData
+	}
+	plaintext := data[:len(data)-checksumSize]
+	if binary.BigEndian.Uint32(data[len(plaintext):]) != crc32.Checksum(plaintext, castagnoli) {
+		return nil, ErrChecksumMismatch
+	}
+	return plaintext, nil
+}
+
+// DecryptDiagnostic decrypts like DecryptRaw and classifies the outcome, to help
+// tell a wrong passphrase from data damaged in a pipeline. Only damage to the
+// header or the blob's lengths is told apart from a wrong passphrase: the AEAD
+// cannot tell a corrupted body from the wrong key, and both are DiagnosisAuthFailed.
+// The checksum of WithPlaintextChecksum, checked on every decryption, catches a
+// faulty encrypting side, which authentication alone cannot.
+func (c *Client) DecryptDiagnostic(encryptedData []byte) ([]byte, Diagnosis, error) {
+	plaintext, err := c.DecryptRaw(encryptedData)
+	switch {
+	case err == nil:
+		return plaintext, DiagnosisOK, nil
+	case errors.Is(err, ErrDecryptFailed):
+		return nil, DiagnosisAuthFailed, err
+	case errors.Is(err, ErrChecksumMismatch):
+		return nil, DiagnosisChecksumMismatch, err
+	case errors.Is(err, ErrInvalidData), errors.Is(err, ErrInvalidParams), errors.Is(err, ErrArgon2VersionMismatch):
+		return nil, DiagnosisMalformed, err
+	default:
+		return nil, DiagnosisOther, err
+	}
+}
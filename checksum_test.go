@@ -0,0 +1,52 @@
+package cryptio
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecryptDiagnostic(t *testing.T) {
+	client, err := NewWithLevelProfile("ChecksumSecret", SecurityUltraFast, ProfileBalanced, WithPlaintextChecksum())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	plaintext := []byte("row 42 of the nightly export")
+	encrypted, err := client.EncryptRaw(plaintext)
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+	if len(encrypted) != client.CiphertextLen(len(plaintext)) {
+		t.Errorf("Expected %d bytes, got %d", client.CiphertextLen(len(plaintext)), len(encrypted))
+	}
+	got, diag, err := client.DecryptDiagnostic(encrypted)
+	if err != nil || diag != DiagnosisOK || string(got) != string(plaintext) {
+		t.Fatalf("DecryptDiagnostic = %q, %v, %v; want %q, OK", got, diag, err, plaintext)
+	}
+
+	wrongKey, err := NewWithLevelProfile("OtherSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, diag, err := wrongKey.DecryptDiagnostic(encrypted); diag != DiagnosisAuthFailed || !errors.Is(err, ErrDecryptFailed) {
+		t.Errorf("Wrong passphrase: got %v, %v; want AuthFailed", diag, err)
+	}
+
+	corrupted := append([]byte{}, encrypted...)
+	corrupted[19] = 200 // cipher byte
+	for name, bad := range map[string][]byte{"truncated": encrypted[:headerSize+3], "corrupted header": corrupted} {
+		if _, diag, err := client.DecryptDiagnostic(bad); diag != DiagnosisMalformed || err == nil {
+			t.Errorf("%s: got %v, %v; want Malformed", name, diag, err)
+		}
+	}
+
+	// An authentic blob whose checksum is wrong can only come from a faulty sealer.
+	h := client.newHeader()
+	h.flags |= flagChecksum
+	faulty, err := client.sealHeader(nil, []byte("data\x00\x00\x00\x00"), nil, h)
+	if err != nil {
+		t.Fatalf("sealHeader failed: %v", err)
+	}
+	if _, diag, err := client.DecryptDiagnostic(faulty); diag != DiagnosisChecksumMismatch || !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Faulty sealer: got %v, %v; want ChecksumMismatch", diag, err)
+	}
+}
@@ -0,0 +1,46 @@
+package cryptio
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"sync"
+)
+
+// Ciphertext is an encrypted blob whose textual encodings are computed on first use.
+type Ciphertext struct {
+	raw    []byte
+	enc    *base64.Encoding
+	prefix string
+
+	b64Once sync.Once
+	b64     string
+	hexOnce sync.Once
+	hex     string
+}
+
+// EncryptFull encrypts plaintext like EncryptRaw and returns the blob as a
+// Ciphertext, for callers needing several of its forms.
+func (c *Client) EncryptFull(plaintext []byte) (*Ciphertext, error) {
+	raw, err := c.EncryptRaw(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return &Ciphertext{raw: raw, enc: c.base64Encoding(), prefix: c.outputPrefix}, nil
+}
+
+// Raw returns the blob, as EncryptRaw would. It must not be modified.
+func (ct *Ciphertext) Raw() []byte {
+	return ct.raw
+}
+
+// Base64 returns the blob as Encrypt would, with the client's prefix and base64 encoding.
+func (ct *Ciphertext) Base64() string {
+	ct.b64Once.Do(func() { ct.b64 = ct.prefix + ct.enc.EncodeToString(ct.raw) })
+	return ct.b64
+}
+
+// Hex returns the blob in lowercase hexadecimal.
+func (ct *Ciphertext) Hex() string {
+	ct.hexOnce.Do(func() { ct.hex = hex.EncodeToString(ct.raw) })
+	return ct.hex
+}
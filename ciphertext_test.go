@@ -0,0 +1,36 @@
+package cryptio
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+)
+
+func TestEncryptFull(t *testing.T) {
+	client, err := NewWithLevelProfile("FullSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	ct, err := client.EncryptFull([]byte("both forms"))
+	if err != nil {
+		t.Fatalf("EncryptFull failed: %v", err)
+	}
+
+	fromBase64, err := base64.StdEncoding.DecodeString(ct.Base64())
+	if err != nil || !bytes.Equal(fromBase64, ct.Raw()) {
+		t.Errorf("Base64 form does not match the raw blob: %v", err)
+	}
+	fromHex, err := hex.DecodeString(ct.Hex())
+	if err != nil || !bytes.Equal(fromHex, ct.Raw()) {
+		t.Errorf("Hex form does not match the raw blob: %v", err)
+	}
+	if ct.Base64() != ct.Base64() {
+		t.Error("Base64 should be stable across calls")
+	}
+
+	plaintext, err := client.Decrypt(ct.Base64())
+	if err != nil || plaintext != "both forms" {
+		t.Errorf("Decrypt of the base64 form failed: %q, %v", plaintext, err)
+	}
+}
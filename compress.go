@@ -0,0 +1,55 @@
+package cryptio
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// Compression selects whether plaintexts are compressed before encryption.
+type Compression int
+
+const (
+	CompressionNone Compression = iota // Never compress (default)
+	CompressionAuto                    // Gzip, kept only when smaller than the plaintext
+)
+
+// compress gzips plaintext with CompressionAuto and returns the result, flagged in
+// h, when it is smaller; incompressible data is returned unchanged.
+func (c *Client) compress(h *header, plaintext []byte) []byte {
+	if c.compression != CompressionAuto || len(plaintext) == 0 {
+		return plaintext
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(plaintext); err != nil {
+		return plaintext
+	}
+	if err := zw.Close(); err != nil || buf.Len() >= len(plaintext) {
+		return plaintext
+	}
+	h.flags |= flagCompressed
+	return buf.Bytes()
+}
+
+// decompress reverses compress, stopping with ErrSizeLimitExceeded past the
+// WithMaxDecryptedSize limit so a small blob cannot expand without bound.
+func (c *Client) decompress(compressed []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidData, err)
+	}
+	var r io.Reader = zr
+	if c.maxDecrypted > 0 {
+		r = io.LimitReader(zr, c.maxDecrypted+1)
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidData, err)
+	}
+	if c.maxDecrypted > 0 && int64(len(plaintext)) > c.maxDecrypted {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrSizeLimitExceeded, c.maxDecrypted)
+	}
+	return plaintext, nil
+}
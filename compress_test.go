@@ -0,0 +1,68 @@
+package cryptio
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestCompressionAuto(t *testing.T) {
+	client, err := NewWithLevelProfile("CompressSecret", SecurityUltraFast, ProfileBalanced, WithCompression(CompressionAuto))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	compressible := bytes.Repeat([]byte("log line repeated over and over\n"), 1000)
+	incompressible := make([]byte, 4096)
+	if _, err := rand.Read(incompressible); err != nil {
+		t.Fatalf("rand.Read failed: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name       string
+		plaintext  []byte
+		compressed bool
+	}{
+		{"compressible", compressible, true},
+		{"incompressible", incompressible, false},
+		{"empty", nil, false},
+	} {
+		encrypted, err := client.EncryptRaw(tc.plaintext)
+		if err != nil {
+			t.Fatalf("%s: EncryptRaw failed: %v", tc.name, err)
+		}
+		h, _, err := readHeader(encrypted)
+		if err != nil {
+			t.Fatalf("%s: readHeader failed: %v", tc.name, err)
+		}
+		if got := h.flags&flagCompressed != 0; got != tc.compressed {
+			t.Errorf("%s: compressed flag = %v, want %v", tc.name, got, tc.compressed)
+		}
+		if !tc.compressed && len(encrypted) != client.CiphertextLen(len(tc.plaintext)) {
+			t.Errorf("%s: incompressible data should not expand, got %d bytes", tc.name, len(encrypted))
+		}
+		if tc.compressed && len(encrypted) >= len(tc.plaintext) {
+			t.Errorf("%s: expected a blob smaller than the plaintext, got %d bytes", tc.name, len(encrypted))
+		}
+		decrypted, err := client.DecryptRaw(encrypted)
+		if err != nil {
+			t.Fatalf("%s: DecryptRaw failed: %v", tc.name, err)
+		}
+		if !bytes.Equal(decrypted, tc.plaintext) {
+			t.Errorf("%s: decrypted data does not match", tc.name)
+		}
+	}
+
+	// The decompressed size is bounded by WithMaxDecryptedSize.
+	limited, err := NewWithLevelProfile("CompressSecret", SecurityUltraFast, ProfileBalanced, WithMaxDecryptedSize(1024))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	encrypted, err := client.EncryptRaw(compressible)
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+	if _, err := limited.DecryptRaw(encrypted); !errors.Is(err, ErrSizeLimitExceeded) {
+		t.Errorf("Expected ErrSizeLimitExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+package cryptio
+
+import (
+	"runtime"
+
+	"golang.org/x/sys/cpu"
+)
+
+// HasAESAcceleration reports whether the CPU has the instructions Go uses for
+// hardware AES-GCM (AES-NI and CLMUL on amd64, the AES and PMULL extensions on
+// arm64, CPACF on s390x). Without them AES-GCM is markedly slower and
+// ChaCha20-Poly1305 is usually the better choice.
+func HasAESAcceleration() bool {
+	switch runtime.GOARCH {
+	case "amd64", "386":
+		return cpu.X86.HasAES && cpu.X86.HasPCLMULQDQ
+	case "arm64":
+		return cpu.ARM64.HasAES && cpu.ARM64.HasPMULL
+	case "s390x":
+		return cpu.S390X.HasAES && cpu.S390X.HasAESGCM
+	default:
+		return false
+	}
+}
@@ -1,12 +1,22 @@
 package cryptio
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
+	"context"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
 
 	"golang.org/x/crypto/argon2"
 )
@@ -67,19 +77,76 @@ func (pf Argon2Profile) String() string {
 	}
 }
 
-// securityParams holds the Argon2id configuration for encryption.
-type securityParams struct {
-	SaltSize     int
-	KeySize      uint32
-	NonceSize    int
-	ArgonTime    uint32
-	ArgonMem     uint32
-	ArgonThreads uint8
+// Params holds the Argon2id configuration for encryption.
+type Params struct {
+	SaltSize     int    // Salt length in bytes
+	KeySize      uint32 // Derived key length in bytes
+	NonceSize    int    // AEAD nonce length in bytes
+	ArgonTime    uint32 // Argon2id iterations
+	ArgonMem     uint32 // Argon2id memory in KiB
+	ArgonThreads uint8  // Argon2id parallelism
+}
+
+// Minimum values accepted for any Params, whatever their origin.
+const (
+	minSaltSize  = 16 // 128-bit salt, RFC 9106 recommendation
+	gcmNonceSize = 12 // standard AES-GCM nonce size
+	gcmTagSize   = 16 // AES-GCM authentication tag size
+	minTagSize   = 12 // shortest tag accepted by cipher.NewGCMWithTagSize
+
+	maxHeaderField = 255 // sizes are stored as single bytes in the blob header
+	maxAutoThreads = 4   // Argon2 lanes used by WithAutoThreads on large machines
+)
+
+// validate rejects parameter sets that would weaken or break encryption.
+// Every Params must pass it before being used by a Client.
+func (p Params) validate() error {
+	if err := p.validateSizes(); err != nil {
+		return err
+	}
+	if p.ArgonTime < 1 {
+		return fmt.Errorf("%w: Argon2 time cost must be at least 1", ErrInvalidParams)
+	}
+	if p.ArgonThreads < 1 {
+		return fmt.Errorf("%w: Argon2 parallelism must be at least 1", ErrInvalidParams)
+	}
+	return nil
+}
+
+// validateSizes is validate without the Argon2 costs.
+func (p Params) validateSizes() error {
+	if p.SaltSize < minSaltSize {
+		return fmt.Errorf("%w: salt size %d is below the minimum of %d bytes", ErrInvalidParams, p.SaltSize, minSaltSize)
+	}
+	if p.SaltSize > maxHeaderField {
+		return fmt.Errorf("%w: salt size %d exceeds %d bytes", ErrInvalidParams, p.SaltSize, maxHeaderField)
+	}
+	if p.NonceSize < 1 || p.NonceSize > maxHeaderField {
+		return fmt.Errorf("%w: nonce size %d is outside 1-%d bytes", ErrInvalidParams, p.NonceSize, maxHeaderField)
+	}
+	switch p.KeySize {
+	case 16, 24, 32:
+	default:
+		return fmt.Errorf("%w: key size %d is not a valid AES key length (16, 24 or 32 bytes)", ErrInvalidParams, p.KeySize)
+	}
+	return nil
+}
+
+// validateGCMSizes checks the standard library can build an AES-GCM with these nonce and tag sizes.
+// Non-standard nonces (cipher.NewGCMWithNonceSize) only come with the full 16-byte tag.
+func validateGCMSizes(nonceSize, tagSize int) error {
+	if tagSize < minTagSize || tagSize > gcmTagSize {
+		return fmt.Errorf("%w: tag size %d is outside %d-%d bytes", ErrInvalidParams, tagSize, minTagSize, gcmTagSize)
+	}
+	if nonceSize != gcmNonceSize && tagSize != gcmTagSize {
+		return fmt.Errorf("%w: a %d-byte nonce requires the %d-byte tag", ErrInvalidParams, nonceSize, gcmTagSize)
+	}
+	return nil
 }
 
 // --- Base param tables ---
 
-var argon2Profiles = map[Argon2Profile]securityParams{
+var argon2Profiles = map[Argon2Profile]Params{
 	ProfileRAMHeavy: {
 		SaltSize:     16,
 		KeySize:      32,
@@ -122,7 +189,7 @@ var argon2Profiles = map[Argon2Profile]securityParams{
 	},
 }
 
-var securityLevels = map[SecurityLevel]securityParams{
+var securityLevels = map[SecurityLevel]Params{
 	SecurityUltraFast: {
 		SaltSize:     16,
 		KeySize:      32,
@@ -177,8 +244,8 @@ func maxParam[T ~int | ~uint8 | ~uint32](a, b T) T {
 
 // mergeParams combines a profile and a security level to produce the most "refined" Argon2id config.
 // Both profile and security level are required.
-func mergeParams(level SecurityLevel, profile Argon2Profile) (securityParams, error) {
-	base := securityParams{}
+func mergeParams(level SecurityLevel, profile Argon2Profile) (Params, error) {
+	base := Params{}
 	p, okp := argon2Profiles[profile]
 	l, okl := securityLevels[level]
 
@@ -196,96 +263,723 @@ func mergeParams(level SecurityLevel, profile Argon2Profile) (securityParams, er
 	base.SaltSize = maxParam(p.SaltSize, l.SaltSize)
 	base.KeySize = maxParam(p.KeySize, l.KeySize)
 	base.NonceSize = maxParam(p.NonceSize, l.NonceSize)
+	if err := base.validate(); err != nil {
+		return Params{}, err
+	}
 	return base, nil
 }
 
+// checkPolicy returns ErrPolicyViolation when p is weaker than the WithRejectBelowPolicy floor.
+func (c *Client) checkPolicy(p Params) error {
+	if c.minPolicy == nil {
+		return nil
+	}
+	return p.checkFloor(*c.minPolicy)
+}
+
+// checkFloor returns ErrPolicyViolation when p is weaker than floor.
+func (p Params) checkFloor(floor Params) error {
+	switch {
+	case p.ArgonMem < floor.ArgonMem:
+		return fmt.Errorf("%w: Argon2 memory %d KiB is below %d KiB", ErrPolicyViolation, p.ArgonMem, floor.ArgonMem)
+	case p.ArgonTime < floor.ArgonTime:
+		return fmt.Errorf("%w: Argon2 time cost %d is below %d", ErrPolicyViolation, p.ArgonTime, floor.ArgonTime)
+	case p.SaltSize < floor.SaltSize:
+		return fmt.Errorf("%w: salt size %d is below %d bytes", ErrPolicyViolation, p.SaltSize, floor.SaltSize)
+	case p.KeySize < floor.KeySize:
+		return fmt.Errorf("%w: key size %d is below %d bytes", ErrPolicyViolation, p.KeySize, floor.KeySize)
+	}
+	return nil
+}
+
+// ResolveParams returns the parameters a client created with level and profile would use,
+// without needing a passphrase or running any key derivation.
+func ResolveParams(level SecurityLevel, profile Argon2Profile) (Params, error) {
+	return mergeParams(level, profile)
+}
+
+// ValidateParams reports whether level and profile resolve to usable parameters,
+// without a passphrase or allocating Argon2 memory, so startup code and health
+// checks can fail fast on a misconfiguration.
+func ValidateParams(level SecurityLevel, profile Argon2Profile) error {
+	_, err := mergeParams(level, profile)
+	return err
+}
+
+// AllSecurityLevels returns every security level, from the weakest to the strongest.
+func AllSecurityLevels() []SecurityLevel {
+	return []SecurityLevel{SecurityUltraFast, SecurityStandard, SecurityMedium, SecurityHigh, SecurityExtreme}
+}
+
+// AllProfiles returns every Argon2 profile, from the most memory-hungry to the most CPU-bound.
+func AllProfiles() []Argon2Profile {
+	return []Argon2Profile{ProfileRAMHeavy, ProfileBalanced, ProfileTradeoff, ProfileCPUFavor, ProfileCPUHeavy}
+}
+
+var levelDescriptions = map[SecurityLevel]string{
+	SecurityUltraFast: "tests and constrained devices only, not for production",
+	SecurityStandard:  "OWASP recommended (default)",
+	SecurityMedium:    "NIST and enterprise requirements",
+	SecurityHigh:      "critical, health and finance data",
+	SecurityExtreme:   "vaults and long-lived secrets",
+}
+
+var profileDescriptions = map[Argon2Profile]string{
+	ProfileRAMHeavy: "favors memory over passes",
+	ProfileBalanced: "balances memory and passes (default)",
+	ProfileTradeoff: "trades memory for passes",
+	ProfileCPUFavor: "favors passes over memory",
+	ProfileCPUHeavy: "favors passes, least memory",
+}
+
+// Describe returns a human description of level and profile with their resolved
+// cost, for UIs and command-line help, e.g.
+// "Standard/Balanced: OWASP recommended (default); ... Argon2id m=64 MiB, t=2, p=1".
+func Describe(level SecurityLevel, profile Argon2Profile) string {
+	p, err := mergeParams(level, profile)
+	if err != nil {
+		return fmt.Sprintf("%v/%v: %v", level, profile, err)
+	}
+	_, approx := p.EstimatedCost()
+	return fmt.Sprintf("%v/%v: %s; %s. Argon2id m=%d MiB, t=%d, p=%d, about %v per key derivation",
+		level, profile, levelDescriptions[level], profileDescriptions[profile],
+		p.ArgonMem/1024, p.ArgonTime, p.ArgonThreads, approx.Round(time.Millisecond))
+}
+
+// argon2NsPerKiBPass is the time one Argon2id lane takes to fill 1 KiB of memory
+// once, measured with BenchmarkEncryptDecrypt_AllCombinations on an amd64 laptop.
+const argon2NsPerKiBPass = 650
+
+// EstimatedCost returns the memory a key derivation with p allocates, which is
+// exact, and a rough duration extrapolated from a reference benchmark. Actual
+// times vary with the CPU, but estimates are comparable between parameter sets.
+func (p Params) EstimatedCost() (memBytes uint64, approxTime time.Duration) {
+	memBytes = uint64(p.ArgonMem) * 1024
+	work := uint64(p.ArgonTime) * uint64(p.ArgonMem) * argon2NsPerKiBPass
+	lanes := uint64(max(p.ArgonThreads, 1))
+	return memBytes, time.Duration(work / lanes) //nolint:gosec // bounded by the uint32 inputs
+}
+
 // --- Main API ---
 
 // Client contains the passphrase and security parameters.
 type Client struct {
-	passphrase []byte
-	params     securityParams
+	passphrase     []byte
+	mu             sync.RWMutex // guards params, see Reconfigure
+	params         Params
+	level          SecurityLevel
+	profile        Argon2Profile
+	derivedNonce   bool
+	allowEmpty     bool
+	streamIndex    bool
+	tagSize        int
+	nonceSize      int
+	maxDecrypted   int64
+	aadMatch       AADMatch
+	cipher         Cipher
+	rand           io.Reader
+	saltReader     io.Reader
+	autoThreads    bool
+	fixedSalt      []byte
+	nsKey          *namespaceKey // guarded by mu
+	derivations    atomic.Int64  // Argon2 runs, for tests
+	minPolicy      *Params
+	nsMessages     atomic.Uint64 // blobs sealed with the namespace key
+	logger         *slog.Logger
+	padMin         int
+	padMax         int
+	deterministic  bool
+	saltContext    []byte
+	unpaddedBase64 bool
+	customBase64   *base64.Encoding
+	subkeys        bool
+	compression    Compression
+	defaultAAD     []byte
+	metrics        Metrics
+	fallbackParams []Params
+	outputPrefix   string
+	checksum       bool
+	encryptedNames bool
+	saltSize       int
+	keyCache       *keyCache
+	entropyCheck   func([]byte) error
+	chunkSize      int
+	implicitParams bool
+
+	machineSource MachineIDSource
+	machineID     []byte
+	pepper        []byte
 }
 
-// New creates a new client using both a SecurityLevel and an Argon2Profile.
-// Both arguments are required.
-func New(passphrase string, level SecurityLevel, profile Argon2Profile) (*Client, error) {
-	params, err := mergeParams(level, profile)
+// New creates a new client configured by opts. Without WithLevel and WithProfile,
+// it uses SecurityStandard and ProfileBalanced.
+// An empty passphrase is rejected with ErrEmptyPassphrase unless WithAllowEmptyPassphrase is given.
+func New(passphrase string, opts ...Option) (*Client, error) {
+	c := &Client{
+		passphrase: []byte(passphrase),
+		level:      SecurityStandard,
+		profile:    ProfileBalanced,
+		tagSize:    gcmTagSize,
+		rand:       rand.Reader,
+		saltReader: rand.Reader,
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	params, err := c.resolveParams(c.level, c.profile)
 	if err != nil {
 		return nil, err
 	}
-	return &Client{
-		passphrase: []byte(passphrase),
-		params:     params,
-	}, nil
+	c.params = params
+	if err := c.validateDeterministic(); err != nil {
+		return nil, err
+	}
+	if c.subkeys && c.fixedSalt == nil {
+		return nil, errors.New("WithNonceDerivedSubkeys requires a namespace client, see NewNamespace")
+	}
+	if len(c.passphrase) == 0 && !c.allowEmpty {
+		return nil, ErrEmptyPassphrase
+	}
+	if c.machineSource != nil {
+		c.machineID, err = c.machineSource.MachineID()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// NewWithLevelProfile creates a new client using both a SecurityLevel and an
+// Argon2Profile, like New with WithLevel and WithProfile.
+func NewWithLevelProfile(passphrase string, level SecurityLevel, profile Argon2Profile, opts ...Option) (*Client, error) {
+	return New(passphrase, append([]Option{WithLevel(level), WithProfile(profile)}, opts...)...)
+}
+
+// resolveParams merges level and profile and applies the client's option overrides.
+func (c *Client) resolveParams(level SecurityLevel, profile Argon2Profile) (Params, error) {
+	params, err := mergeParams(level, profile)
+	if err != nil {
+		return Params{}, err
+	}
+	if c.saltSize != 0 {
+		params.SaltSize = c.saltSize
+	}
+	if c.fixedSalt != nil {
+		if c.saltSize != 0 && c.saltSize != len(c.fixedSalt) {
+			return Params{}, fmt.Errorf("%w: uniform salt size %d differs from the %d-byte namespace salt", ErrInvalidParams, c.saltSize, len(c.fixedSalt))
+		}
+		params.SaltSize = len(c.fixedSalt)
+	}
+	if c.autoThreads {
+		params.ArgonThreads = uint8(min(runtime.GOMAXPROCS(0), maxAutoThreads)) //nolint:gosec // at most maxAutoThreads
+	}
+	if n := c.cipher.nonceSize(); n != 0 {
+		if c.nonceSize != 0 && c.nonceSize != n {
+			return Params{}, fmt.Errorf("%w: %v requires a %d-byte nonce", ErrInvalidParams, c.cipher, n)
+		}
+		params.NonceSize = n
+	} else if c.nonceSize != 0 {
+		params.NonceSize = c.nonceSize
+	}
+	h := header{cipher: c.cipher, params: params, tagSize: c.tagSize}
+	if c.subkeys {
+		if c.nonceSize != 0 {
+			return Params{}, errors.New("WithNonceDerivedSubkeys cannot be combined with WithNonceSize")
+		}
+		h.flags |= flagSubkey
+		h.params.NonceSize = subkeyNonceSize
+		params.NonceSize = subkeyNonceSize
+	}
+	if err := h.validateCipher(); err != nil {
+		return Params{}, err
+	}
+	return params, nil
+}
+
+// Reconfigure switches the client to level and profile, keeping its passphrase and
+// options. Later encryptions use the new parameters, while existing blobs and
+// streams still decrypt with those recorded in their header. Data written with
+// WithImplicitParams records no costs and needs a client with the old ones. It
+// is safe to call concurrently with encryption and decryption.
+func (c *Client) Reconfigure(level SecurityLevel, profile Argon2Profile) error {
+	params, err := c.resolveParams(level, profile)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.params, c.level, c.profile = params, level, profile
+	c.mu.Unlock()
+	return nil
+}
+
+// currentParams returns the parameters new encryptions must use.
+func (c *Client) currentParams() Params {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.params
+}
+
+// randomAttempts bounds how many times readRandom tries a failing source.
+const randomAttempts = 3
+
+// readRandom fills buf from r and rejects an all-zero result, the typical output
+// of a broken or misconfigured generator, with ErrWeakRandomness. Failed or short
+// reads are retried, up to randomAttempts in all, before ErrRandomnessUnavailable.
+func readRandom(r io.Reader, buf []byte) error {
+	var err error
+	for range randomAttempts {
+		if _, err = io.ReadFull(r, buf); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%w after %d attempts: %w", ErrRandomnessUnavailable, randomAttempts, err)
+	}
+	for _, b := range buf {
+		if b != 0 {
+			return nil
+		}
+	}
+	return ErrWeakRandomness
+}
+
+// readFresh is readRandom followed by the WithEntropyCheck check, if any, for the
+// salts and nonces of new messages.
+func (c *Client) readFresh(r io.Reader, buf []byte) error {
+	if err := readRandom(r, buf); err != nil {
+		return err
+	}
+	if c.entropyCheck != nil {
+		if err := c.entropyCheck(buf); err != nil {
+			return fmt.Errorf("%w: entropy check: %w", ErrWeakRandomness, err)
+		}
+	}
+	return nil
+}
+
+// logDebug emits a debug event to the WithLogger logger, if any.
+// Callers must never pass plaintext, keys or passphrase-derived values.
+func (c *Client) logDebug(msg string, attrs ...slog.Attr) {
+	if c.logger != nil {
+		c.logger.LogAttrs(context.Background(), slog.LevelDebug, "cryptio: "+msg, attrs...)
+	}
+}
+
+// keyedHash returns HMAC-SHA256(key, data).
+func keyedHash(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// kdfPassword returns the secret fed to Argon2id: the passphrase, combined with
+// the pepper and bound to the machine when those are configured.
+func (c *Client) kdfPassword() []byte {
+	password := c.passphrase
+	if c.pepper != nil {
+		password = keyedHash(c.pepper, password)
+	}
+	if c.machineID != nil {
+		password = keyedHash(c.machineID, password)
+	}
+	return password
 }
 
 // deriveKey generates a key using Argon2id from the passphrase and salt.
-func (c *Client) deriveKey(salt []byte) []byte {
-	return argon2.IDKey(c.passphrase, salt, c.params.ArgonTime, c.params.ArgonMem, c.params.ArgonThreads, c.params.KeySize)
+// Keys derived from a namespace salt are cached, see NewNamespace, as well as
+// recent keys with WithKeyCache.
+func (c *Client) deriveKey(salt []byte, p Params) ([]byte, error) {
+	if key, ok := c.cachedKey(salt, p); ok {
+		return key, nil
+	}
+	if c.keyCache != nil {
+		if key, ok := c.keyCache.get(salt, p); ok {
+			return key, nil
+		}
+	}
+	key, err := c.argon2Key(salt, p)
+	if err != nil {
+		return nil, err
+	}
+	c.cacheKey(salt, p, key)
+	if c.keyCache != nil {
+		c.keyCache.put(salt, p, key)
+	}
+	return key, nil
 }
 
-// EncryptRaw encrypts a byte slice and returns the encrypted byte slice (salt+nonce+ciphertext).
-func (c *Client) EncryptRaw(plaintext []byte) ([]byte, error) {
-	salt := make([]byte, c.params.SaltSize)
-	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+// argon2Key runs Argon2id over the passphrase and salt, bypassing the caches.
+// It fails with ErrInsufficientMemory rather than risk the process being killed,
+// see checkArgon2Memory.
+func (c *Client) argon2Key(salt []byte, p Params) ([]byte, error) {
+	if err := checkArgon2Memory(p); err != nil {
 		return nil, err
 	}
-	key := c.deriveKey(salt)
-	block, err := aes.NewCipher(key)
+	c.derivations.Add(1)
+	start := time.Now()
+	kdfSalt := salt
+	if len(c.saltContext) > 0 {
+		kdfSalt = append(append(make([]byte, 0, len(salt)+len(c.saltContext)), salt...), c.saltContext...)
+	}
+	key := argon2.IDKey(c.kdfPassword(), kdfSalt, p.ArgonTime, p.ArgonMem, p.ArgonThreads, p.KeySize)
+	elapsed := time.Since(start)
+	if c.metrics != nil {
+		c.metrics.KeyDerived(elapsed)
+	}
+	c.logDebug("key derivation finished",
+		slog.Duration("duration", elapsed),
+		slog.Uint64("argon_time", uint64(p.ArgonTime)),
+		slog.Uint64("argon_mem_kib", uint64(p.ArgonMem)),
+		slog.Int("argon_threads", int(p.ArgonThreads)),
+	)
+	return key, nil
+}
+
+// Bounds on the length of DeriveKeyForExternal keys: the shortest Argon2 output
+// allowed by RFC 9106, and a cap well above any key size in use.
+const (
+	minExternalKeySize = 4
+	maxExternalKeySize = 1024
+)
+
+// externalKeyContext prefixes the salt of DeriveKeyForExternal keys, so that they
+// never equal the key of a blob with the same salt.
+var externalKeyContext = []byte("cryptio external key\x00")
+
+// DeriveKeyForExternal runs Argon2id with the client's passphrase and cost
+// parameters and returns keyLen bytes, at most 1024, for use with another AEAD
+// or library (nacl/secretbox, ...). The output is deterministic for a given salt,
+// which must be at least 16 bytes and stored by the caller alongside the data.
+// The salt is domain-separated from blob salts, so the key differs from that of
+// any blob, and it is never cached.
+func (c *Client) DeriveKeyForExternal(salt []byte, keyLen uint32) ([]byte, error) {
+	if len(salt) < minSaltSize {
+		return nil, fmt.Errorf("%w: salt size %d is below the minimum of %d bytes", ErrInvalidParams, len(salt), minSaltSize)
+	}
+	if keyLen < minExternalKeySize || keyLen > maxExternalKeySize {
+		return nil, fmt.Errorf("%w: key length %d is outside %d-%d bytes", ErrInvalidParams, keyLen, minExternalKeySize, maxExternalKeySize)
+	}
+	p := c.currentParams()
+	p.KeySize = keyLen
+	separated := make([]byte, 0, len(externalKeyContext)+len(salt))
+	separated = append(append(separated, externalKeyContext...), salt...)
+	return c.argon2Key(separated, p)
+}
+
+// newHeader returns the header describing new encryptions by this client.
+func (c *Client) newHeader() header {
+	h := header{cipher: c.cipher, params: c.currentParams(), tagSize: c.tagSize}
+	if c.derivedNonce {
+		h.flags |= flagDerivedNonce
+	}
+	if c.deterministic {
+		h.flags |= flagDeterministic
+	}
+	if c.subkeys {
+		h.flags |= flagSubkey
+	}
+	if c.implicitParams {
+		h.flags |= flagImplicitParams
+	}
+	return h
+}
+
+// seal encrypts plaintext, authenticating aad, and appends header+salt+nonce+ciphertext to dst.
+func (c *Client) seal(dst, plaintext, aad []byte) ([]byte, error) {
+	return c.sealAs(dst, plaintext, aad, c.newHeader())
+}
+
+// sealAs is seal with header h, which checksum, compression and padding complete.
+func (c *Client) sealAs(dst, plaintext, aad []byte, h header) ([]byte, error) {
+	plaintext, err := c.encodePlaintext(&h, plaintext)
 	if err != nil {
 		return nil, err
 	}
-	nonce := make([]byte, c.params.NonceSize)
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+	return c.sealHeader(dst, plaintext, aad, h)
+}
+
+// encodePlaintext adds the checksum, compresses and pads plaintext as configured,
+// recording each step in h. openHeader undoes them in reverse order.
+func (c *Client) encodePlaintext(h *header, plaintext []byte) ([]byte, error) {
+	return c.pad(h, c.compress(h, c.addChecksum(h, plaintext)))
+}
+
+// sealHeader is seal with an explicit header instead of the client's.
+func (c *Client) sealHeader(dst, plaintext, aad []byte, h header) ([]byte, error) {
+	derived := h.flags&flagDerivedNonce != 0
+	salt := make([]byte, h.params.SaltSize)
+	if err := c.newSalt(salt); err != nil {
 		return nil, err
 	}
-	gcm, err := cipher.NewGCM(block)
+	if err := c.countNamespaceNonce(h.params.NonceSize); err != nil {
+		return nil, err
+	}
+	start := len(dst)
+	dst = writeHeader(dst, h)
+	ad := headerAAD(dst[start:len(dst):len(dst)], h) // Seal only writes past len(dst)
+	if len(aad) > 0 {
+		ad = append(append([]byte{}, aad...), ad...)
+	}
+
+	var nonce []byte
+	var err error
+	switch {
+	case derived:
+		nonce, err = deriveNonce(salt, h.params.NonceSize)
+		if err != nil {
+			return nil, err
+		}
+	case h.flags&flagDeterministic != 0:
+		nonce, err = c.syntheticNonce(salt, h.params, ad, plaintext)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		nonce = make([]byte, h.params.NonceSize)
+		if err := c.readFresh(c.rand, nonce); err != nil {
+			return nil, err
+		}
+	}
+	gcm, aeadNonce, err := c.messageAEAD(salt, nonce, h)
 	if err != nil {
 		return nil, err
 	}
-	ciphertext := gcm.Seal(nil, nonce, plaintext, nil)
-	finalData := append(append(salt, nonce...), ciphertext...) //nolint:makezero
-	return finalData, nil
+	dst = append(dst, salt...)
+	if !derived {
+		dst = append(dst, nonce...)
+	}
+	if c.metrics != nil {
+		c.metrics.Encrypted()
+	}
+	return gcm.Seal(dst, aeadNonce, plaintext, ad), nil
 }
 
-// DecryptRaw decrypts an encrypted byte slice (salt+nonce+ciphertext).
-func (c *Client) DecryptRaw(encryptedData []byte) ([]byte, error) {
-	minLen := c.params.SaltSize + c.params.NonceSize
-	if len(encryptedData) < minLen {
-		return nil, errors.New("invalid encrypted data")
-	}
-	salt := encryptedData[:c.params.SaltSize]
-	nonce := encryptedData[c.params.SaltSize : c.params.SaltSize+c.params.NonceSize]
-	ciphertext := encryptedData[c.params.SaltSize+c.params.NonceSize:]
-	key := c.deriveKey(salt)
-	block, err := aes.NewCipher(key)
+// open decrypts header+salt+nonce+ciphertext, checking that it was sealed with aad.
+// The parameters recorded in the header are used, not the client's own, and must
+// meet the WithRejectBelowPolicy floor.
+func (c *Client) open(encryptedData, aad []byte) ([]byte, error) {
+	return c.openInto(nil, encryptedData, aad)
+}
+
+// openInto is open appending the plaintext to dst.
+func (c *Client) openInto(dst, encryptedData, aad []byte) ([]byte, error) {
+	if c.minPolicy != nil {
+		if h, _, err := c.readBlobHeader(encryptedData); err == nil {
+			if err := c.checkPolicy(h.params); err != nil {
+				return nil, err
+			}
+		}
+	}
+	plaintext, _, err := c.openHeader(dst, encryptedData, aad)
+	return plaintext, err
+}
+
+// openHeader is openInto, also returning the decoded blob header.
+func (c *Client) openHeader(dst, encryptedData, aad []byte) ([]byte, header, error) {
+	h, rest, err := c.readBlobHeader(encryptedData)
 	if err != nil {
-		return nil, err
+		return nil, header{}, err
+	}
+	if h.flags&flagIntegrity != 0 {
+		return nil, header{}, fmt.Errorf("%w: integrity-only data is not encrypted, use VerifyIntegrity", ErrInvalidData)
+	}
+	if len(encryptedData) < h.minBlobSize() {
+		return nil, header{}, ErrInvalidData
+	}
+	saltSize, nonceSize := h.params.SaltSize, h.storedNonceSize()
+	salt := rest[:saltSize]
+	nonce := rest[saltSize : saltSize+nonceSize]
+	ciphertext := rest[saltSize+nonceSize:]
+	if nonceSize == 0 {
+		nonce, err = deriveNonce(salt, h.params.NonceSize)
+		if err != nil {
+			return nil, header{}, err
+		}
 	}
-	gcm, err := cipher.NewGCM(block)
+	gcm, nonce, err := c.messageAEAD(salt, nonce, h)
 	if err != nil {
-		return nil, err
+		return nil, header{}, err
+	}
+	ad := headerAAD(encryptedData[:h.size()], h)
+	if len(aad) > 0 {
+		ad = append(append([]byte{}, aad...), ad...)
 	}
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	plaintext, err := gcm.Open(dst, nonce, ciphertext, ad)
 	if err != nil {
-		return nil, err
+		c.logDebug("decryption failed authentication", slog.String("cipher", h.cipher.String()), slog.Int("size", len(encryptedData)))
+		if c.metrics != nil {
+			c.metrics.AuthFailed()
+		}
+		return nil, header{}, fmt.Errorf("%w: %w", ErrDecryptFailed, err)
+	}
+	if h.flags&flagPadded != 0 {
+		if plaintext, err = unpad(plaintext); err != nil {
+			return nil, header{}, err
+		}
+	}
+	if h.flags&flagCompressed != 0 {
+		if plaintext, err = c.decompress(plaintext); err != nil {
+			return nil, header{}, err
+		}
+	}
+	if h.flags&flagChecksum != 0 {
+		if plaintext, err = verifyChecksum(plaintext); err != nil {
+			return nil, header{}, err
+		}
+	}
+	if c.metrics != nil {
+		c.metrics.Decrypted()
+	}
+	return plaintext, h, nil
+}
+
+// EncryptRaw encrypts a byte slice and returns the encrypted byte slice (header+salt+nonce+ciphertext).
+// The WithDefaultAAD additional data, if any, is bound to it.
+func (c *Client) EncryptRaw(plaintext []byte) ([]byte, error) {
+	return c.seal(nil, plaintext, c.defaultAAD)
+}
+
+// EncryptRawInto is EncryptRaw appending the blob to dst, like cipher.AEAD.Seal.
+// Reusing a dst with enough capacity (see CiphertextLen) avoids allocating the output.
+func (c *Client) EncryptRawInto(dst, plaintext []byte) ([]byte, error) {
+	return c.seal(dst, plaintext, c.defaultAAD)
+}
+
+// DecryptRaw decrypts an encrypted byte slice (header+salt+nonce+ciphertext).
+// Data without the blob header is taken for the legacy salt+nonce+ciphertext
+// format and decrypted with the client's own parameters.
+func (c *Client) DecryptRaw(encryptedData []byte) ([]byte, error) {
+	if isLegacyBlob(encryptedData) {
+		plaintext, _, err := c.openLegacy(encryptedData, true)
+		return plaintext, err
 	}
-	return plaintext, nil
+	return c.open(encryptedData, c.defaultAAD)
 }
 
-// Encrypt encrypts a string and returns a base64-encoded result.
+// DecryptRawInto is DecryptRaw writing the plaintext into dst[:0] when it has the
+// capacity, like cipher.AEAD.Open, to avoid allocating per call, e.g. when
+// decrypting many blobs from a memory-mapped file. dst and src must not overlap.
+func (c *Client) DecryptRawInto(dst, src []byte) ([]byte, error) {
+	if isLegacyBlob(src) {
+		plaintext, _, err := c.openLegacy(src, true)
+		if err != nil {
+			return nil, err
+		}
+		return append(dst[:0], plaintext...), nil
+	}
+	return c.openInto(dst[:0], src, c.defaultAAD)
+}
+
+// DecryptRawWithInfo decrypts like DecryptRaw and also returns the parameters
+// recorded in the blob, e.g. to re-encrypt data below the current policy.
+// It is the migration path: WithRejectBelowPolicy does not apply to it.
+// For a headerless legacy blob, the info has Version 0 and describes the
+// parameters that opened it, the client's own or a fallback.
+func (c *Client) DecryptRawWithInfo(encryptedData []byte) ([]byte, BlobInfo, error) {
+	if isLegacyBlob(encryptedData) {
+		plaintext, h, err := c.openLegacy(encryptedData, false)
+		if err != nil {
+			return nil, BlobInfo{}, err
+		}
+		info := h.info()
+		info.Version = 0
+		return plaintext, info, nil
+	}
+	plaintext, h, err := c.openHeader(nil, encryptedData, c.defaultAAD)
+	if err != nil {
+		return nil, BlobInfo{}, err
+	}
+	return plaintext, h.info(), nil
+}
+
+// UpgradeIfWeak re-encrypts data with the Argon2 costs, salt and key sizes of
+// target when those recorded in data fall below them, returning the new blob and
+// true, e.g. to upgrade blobs lazily as they are read from storage. Otherwise
+// data is returned unchanged with false. Headerless legacy blobs, whose
+// parameters are unknown, are always upgraded, and blobs with implicit costs
+// (see WithImplicitParams) are judged by the client's. The client's cipher,
+// flags and nonce size apply to the new blob, which records target's costs even
+// with WithImplicitParams; target's nonce size is ignored.
+func (c *Client) UpgradeIfWeak(data []byte, target Params) ([]byte, bool, error) {
+	if err := target.validate(); err != nil {
+		return nil, false, err
+	}
+	if c.fixedSalt != nil && target.SaltSize != len(c.fixedSalt) {
+		return nil, false, fmt.Errorf("%w: target salt size %d differs from the %d-byte namespace salt", ErrInvalidParams, target.SaltSize, len(c.fixedSalt))
+	}
+	var plaintext []byte
+	if isLegacyBlob(data) {
+		var err error
+		if plaintext, _, err = c.openLegacy(data, false); err != nil {
+			return nil, false, err
+		}
+	} else {
+		h, _, err := c.readBlobHeader(data)
+		if err != nil {
+			return nil, false, err
+		}
+		if h.params.checkFloor(target) == nil {
+			return data, false, nil
+		}
+		if plaintext, _, err = c.openHeader(nil, data, c.defaultAAD); err != nil {
+			return nil, false, err
+		}
+	}
+	h := c.newHeader()
+	h.flags &^= flagImplicitParams // the target costs are not the client's
+	nonceSize := h.params.NonceSize
+	h.params = target
+	h.params.NonceSize = nonceSize
+	upgraded, err := c.sealAs(nil, plaintext, c.defaultAAD, h)
+	if err != nil {
+		return nil, false, err
+	}
+	return upgraded, true, nil
+}
+
+// Encrypt encrypts a string and returns a base64-encoded result,
+// preceded by the WithOutputPrefix prefix if any.
 func (c *Client) Encrypt(plaintext string) (string, error) {
 	raw, err := c.EncryptRaw([]byte(plaintext))
 	if err != nil {
 		return "", err
 	}
-	return base64.StdEncoding.EncodeToString(raw), nil
+	return c.outputPrefix + c.base64Encoding().EncodeToString(raw), nil
+}
+
+// EncryptTimed is Encrypt that also reports how long the encryption took, key
+// derivation included, for load testing and capacity planning.
+func (c *Client) EncryptTimed(plaintext string) (string, time.Duration, error) {
+	start := time.Now()
+	encrypted, err := c.Encrypt(plaintext)
+	return encrypted, time.Since(start), err
 }
 
 // Decrypt decrypts a base64-encoded string and returns the plaintext.
+// Whitespace anywhere in the input, such as a trailing newline from a file or
+// line breaks from wrapping, is ignored, and so is the presence or absence of padding.
 func (c *Client) Decrypt(encryptedText string) (string, error) {
-	raw, err := base64.StdEncoding.DecodeString(encryptedText)
+	encoded, ok := strings.CutPrefix(strings.TrimSpace(encryptedText), c.outputPrefix)
+	if !ok {
+		return "", fmt.Errorf("%w: expected %q", ErrWrongPrefix, c.outputPrefix)
+	}
+	var enc *base64.Encoding
+	if c.customBase64 != nil {
+		enc = c.base64Encoding()
+	}
+	return c.decryptBase64(encoded, enc)
+}
+
+// decryptBase64 decrypts base64 text in enc, ignoring whitespace. A nil enc means
+// standard base64, with or without padding.
+func (c *Client) decryptBase64(encoded string, enc *base64.Encoding) (string, error) {
+	encoded = stripSpace(encoded)
+	if enc == nil {
+		enc, encoded = base64.RawStdEncoding, strings.TrimRight(encoded, "=")
+	}
+	raw, err := enc.DecodeString(encoded)
 	if err != nil {
 		return "", err
 	}
@@ -295,3 +989,158 @@ func (c *Client) Decrypt(encryptedText string) (string, error) {
 	}
 	return string(plaintext), nil
 }
+
+// outputSettings are the settings outside the blob header that change what a
+// client writes or accepts. Values that may be secret or long are reduced to a
+// short digest; the zero value describes a client without these options.
+type outputSettings struct {
+	Checksum       bool
+	Compression    Compression
+	PadMin, PadMax int
+	OutputPrefix   string
+	UnpaddedBase64 bool
+	Base64         string // digest of the custom alphabet and padding
+	DefaultAAD     string
+	Pepper         string
+	SaltContext    string
+}
+
+// base64Probe decodes to every base64 digit in order, so that encoding it with a
+// custom encoding spells out the alphabet.
+var base64Probe, _ = base64.StdEncoding.DecodeString("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/")
+
+// settingDigest returns a short hex digest of b, or "" when b is empty.
+func settingDigest(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:4])
+}
+
+// outputSettings returns the client's settings outside the blob header.
+func (c *Client) outputSettings() outputSettings {
+	s := outputSettings{
+		Checksum:       c.checksum,
+		Compression:    c.compression,
+		PadMin:         c.padMin,
+		PadMax:         c.padMax,
+		OutputPrefix:   c.outputPrefix,
+		UnpaddedBase64: c.unpaddedBase64,
+		DefaultAAD:     settingDigest(c.defaultAAD),
+		Pepper:         settingDigest(c.pepper),
+		SaltContext:    settingDigest(c.saltContext),
+	}
+	if c.customBase64 != nil {
+		s.Base64 = settingDigest([]byte(c.customBase64.EncodeToString(base64Probe) + c.customBase64.EncodeToString([]byte{0})))
+	}
+	return s
+}
+
+// ConfigFingerprint returns a short hex digest of the client's encryption settings:
+// cipher, Argon2 costs, sizes and header flags, checksum, compression, padding,
+// output prefix and base64 encoding, and digests of the default AAD, pepper and
+// salt context. The passphrase and machine identifier are not included, so nodes
+// sharing a configuration report the same value.
+func (c *Client) ConfigFingerprint() string {
+	data := writeFullHeader(nil, c.newHeader())
+	if s := c.outputSettings(); s != (outputSettings{}) {
+		data = fmt.Appendf(data, "%+v", s)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+// SameParams reports whether c and other encrypt with the same settings, those
+// ConfigFingerprint covers; passphrases are not compared. Blobs record their
+// header settings, so given the same passphrase either client decrypts the
+// other's blobs despite differences there, unless costs are left implicit (see
+// WithImplicitParams). A different default AAD, pepper or salt context makes
+// decryption fail, as does a different prefix or base64 encoding for Decrypt.
+func (c *Client) SameParams(other *Client) bool {
+	return c.newHeader() == other.newHeader() && c.outputSettings() == other.outputSettings()
+}
+
+// Diff lists the settings that differ between c and other, as
+// "ArgonMem: 65536 != 19456; Cipher: AES-GCM != XChaCha20-Poly1305", or returns
+// an empty string when SameParams holds. The default AAD, pepper, salt context
+// and custom base64 encoding are shown as digests.
+func (c *Client) Diff(other *Client) string {
+	a, b := c.newHeader().info(), other.newHeader().info()
+	sa, sb := c.outputSettings(), other.outputSettings()
+	var diffs []string
+	add := func(name string, x, y any) {
+		if x != y {
+			diffs = append(diffs, fmt.Sprintf("%s: %v != %v", name, x, y))
+		}
+	}
+	add("ArgonTime", a.Params.ArgonTime, b.Params.ArgonTime)
+	add("ArgonMem", a.Params.ArgonMem, b.Params.ArgonMem)
+	add("ArgonThreads", a.Params.ArgonThreads, b.Params.ArgonThreads)
+	add("KeySize", a.Params.KeySize, b.Params.KeySize)
+	add("SaltSize", a.Params.SaltSize, b.Params.SaltSize)
+	add("NonceSize", a.Params.NonceSize, b.Params.NonceSize)
+	add("TagSize", a.TagSize, b.TagSize)
+	add("Cipher", a.Cipher, b.Cipher)
+	add("DerivedNonce", a.DerivedNonce, b.DerivedNonce)
+	add("Deterministic", a.Deterministic, b.Deterministic)
+	add("Subkeys", a.Subkeys, b.Subkeys)
+	add("ImplicitParams", a.ImplicitParams, b.ImplicitParams)
+	add("Checksum", sa.Checksum, sb.Checksum)
+	add("Compression", sa.Compression, sb.Compression)
+	add("PadMin", sa.PadMin, sb.PadMin)
+	add("PadMax", sa.PadMax, sb.PadMax)
+	add("OutputPrefix", fmt.Sprintf("%q", sa.OutputPrefix), fmt.Sprintf("%q", sb.OutputPrefix))
+	add("UnpaddedBase64", sa.UnpaddedBase64, sb.UnpaddedBase64)
+	add("Base64", sa.Base64, sb.Base64)
+	add("DefaultAAD", sa.DefaultAAD, sb.DefaultAAD)
+	add("Pepper", sa.Pepper, sb.Pepper)
+	add("SaltContext", sa.SaltContext, sb.SaltContext)
+	return strings.Join(diffs, "; ")
+}
+
+// stripSpace removes the whitespace characters that may surround or split base64 text.
+func stripSpace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// CiphertextLen returns the size of the EncryptRaw output for a plaintext of plaintextLen bytes.
+// With WithRandomPadding, it is the smallest possible size; with WithCompression,
+// compressible plaintexts produce smaller blobs.
+func (c *Client) CiphertextLen(plaintextLen int) int {
+	return c.Overhead() + plaintextLen
+}
+
+// Overhead returns the bytes EncryptRaw adds to every plaintext: header, salt,
+// nonce, tag and, with WithPlaintextChecksum, checksum. WithRandomPadding adds
+// to it and WithCompression may take from it, depending on the message.
+func (c *Client) Overhead() int {
+	n := c.newHeader().minBlobSize()
+	if c.checksum {
+		n += checksumSize
+	}
+	return n
+}
+
+// Base64Len returns the length of the Encrypt output for a plaintext of plaintextLen bytes.
+func (c *Client) Base64Len(plaintextLen int) int {
+	return len(c.outputPrefix) + c.base64Encoding().EncodedLen(c.CiphertextLen(plaintextLen))
+}
+
+// base64Encoding returns the encoding of Encrypt output: standard base64 unless
+// WithBase64Encoding is given, unpadded with WithoutBase64Padding.
+func (c *Client) base64Encoding() *base64.Encoding {
+	enc := base64.StdEncoding
+	if c.customBase64 != nil {
+		enc = c.customBase64
+	}
+	if c.unpaddedBase64 {
+		return enc.WithPadding(base64.NoPadding)
+	}
+	return enc
+}
@@ -2,8 +2,16 @@ package cryptio
 
 import (
 	"bytes"
+	"crypto/rand"
 	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"runtime/debug"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestEncryptDecrypt(t *testing.T) {
@@ -11,7 +19,7 @@ func TestEncryptDecrypt(t *testing.T) {
 	security := SecurityStandard
 	profile := ProfileBalanced
 
-	client, err := New(pass, security, profile)
+	client, err := NewWithLevelProfile(pass, security, profile)
 	if err != nil {
 		t.Fatalf("Failed to create client: %v", err)
 	}
@@ -37,7 +45,7 @@ func TestEncryptDecryptRaw(t *testing.T) {
 	security := SecurityHigh
 	profile := ProfileRAMHeavy
 
-	client, err := New(pass, security, profile)
+	client, err := NewWithLevelProfile(pass, security, profile)
 	if err != nil {
 		t.Fatalf("Failed to create client: %v", err)
 	}
@@ -62,11 +70,11 @@ func TestDifferentPasswordsFail(t *testing.T) {
 	security := SecurityMedium
 	profile := ProfileCPUFavor
 
-	client1, err := New("PasswordA", security, profile)
+	client1, err := NewWithLevelProfile("PasswordA", security, profile)
 	if err != nil {
 		t.Fatalf("Failed to create client1: %v", err)
 	}
-	client2, err := New("PasswordB", security, profile)
+	client2, err := NewWithLevelProfile("PasswordB", security, profile)
 	if err != nil {
 		t.Fatalf("Failed to create client2: %v", err)
 	}
@@ -81,24 +89,36 @@ func TestDifferentPasswordsFail(t *testing.T) {
 	}
 }
 
-func TestDifferentParamsFail(t *testing.T) {
+func TestDifferentParamsUseHeader(t *testing.T) {
 	pass := "SamePassword"
-	client1, err := New(pass, SecurityStandard, ProfileTradeoff)
+	client1, err := NewWithLevelProfile(pass, SecurityStandard, ProfileTradeoff)
 	if err != nil {
 		t.Fatalf("Failed to create client1: %v", err)
 	}
-	client2, err := New(pass, SecurityHigh, ProfileRAMHeavy)
+	client2, err := NewWithLevelProfile(pass, SecurityHigh, ProfileRAMHeavy)
 	if err != nil {
 		t.Fatalf("Failed to create client2: %v", err)
 	}
 	plaintext := "Mismatch parameters!"
-	ciphertext, err := client1.Encrypt(plaintext)
+	ciphertext, err := client1.EncryptRaw([]byte(plaintext))
 	if err != nil {
 		t.Fatalf("Failed to encrypt: %v", err)
 	}
-	_, err = client2.Decrypt(ciphertext)
+
+	// The blob header records client1's params, so client2 can still decrypt it.
+	plain2, err := client2.DecryptRaw(ciphertext)
+	if err != nil {
+		t.Fatalf("Decryption with different params should use the header, got %v", err)
+	}
+	if string(plain2) != plaintext {
+		t.Errorf("Expected decrypted to be %q, got %q", plaintext, plain2)
+	}
+
+	// Downgrading the recorded params must be detected.
+	ciphertext[9]--
+	_, err = client2.DecryptRaw(ciphertext)
 	if err == nil {
-		t.Error("Decryption should fail with different params, but did not")
+		t.Error("Decryption should fail with tampered header params, but did not")
 	}
 }
 
@@ -107,7 +127,7 @@ func TestBase64InputOutput(t *testing.T) {
 	security := SecurityUltraFast
 	profile := ProfileCPUHeavy
 
-	client, err := New(pass, security, profile)
+	client, err := NewWithLevelProfile(pass, security, profile)
 	if err != nil {
 		t.Fatalf("Failed to create client: %v", err)
 	}
@@ -124,3 +144,998 @@ func TestBase64InputOutput(t *testing.T) {
 		t.Errorf("Ciphertext is not valid base64: %v", err)
 	}
 }
+
+func TestSecurityParamsValidate(t *testing.T) {
+	valid := Params{
+		SaltSize:     16,
+		KeySize:      32,
+		NonceSize:    12,
+		ArgonTime:    1,
+		ArgonMem:     16 * 1024,
+		ArgonThreads: 1,
+	}
+	if err := valid.validate(); err != nil {
+		t.Fatalf("Expected valid params, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(p *Params)
+	}{
+		{"salt too small", func(p *Params) { p.SaltSize = 8 }},
+		{"salt zero", func(p *Params) { p.SaltSize = 0 }},
+		{"salt too large", func(p *Params) { p.SaltSize = 256 }},
+		{"nonce zero", func(p *Params) { p.NonceSize = 0 }},
+		{"nonce too large", func(p *Params) { p.NonceSize = 256 }},
+		{"key size 0", func(p *Params) { p.KeySize = 0 }},
+		{"key size 20", func(p *Params) { p.KeySize = 20 }},
+		{"key size 64", func(p *Params) { p.KeySize = 64 }},
+		{"argon time 0", func(p *Params) { p.ArgonTime = 0 }},
+		{"argon threads 0", func(p *Params) { p.ArgonThreads = 0 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid
+			tt.mutate(&p)
+			err := p.validate()
+			if !errors.Is(err, ErrInvalidParams) {
+				t.Errorf("Expected ErrInvalidParams, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMergeParamsAreValid(t *testing.T) {
+	for _, level := range allSecurityLevels {
+		for _, profile := range allProfiles {
+			if _, err := mergeParams(level, profile); err != nil {
+				t.Errorf("mergeParams(%s, %s) returned %v", level, profile, err)
+			}
+		}
+	}
+}
+
+func TestResolveParams(t *testing.T) {
+	tests := []struct {
+		level   SecurityLevel
+		profile Argon2Profile
+		want    Params
+	}{
+		{SecurityUltraFast, ProfileRAMHeavy, Params{SaltSize: 16, KeySize: 32, NonceSize: 12, ArgonTime: 1, ArgonMem: 47104, ArgonThreads: 1}},
+		{SecurityUltraFast, ProfileCPUHeavy, Params{SaltSize: 16, KeySize: 32, NonceSize: 12, ArgonTime: 5, ArgonMem: 16 * 1024, ArgonThreads: 1}},
+		{SecurityStandard, ProfileBalanced, Params{SaltSize: 16, KeySize: 32, NonceSize: 12, ArgonTime: 2, ArgonMem: 64 * 1024, ArgonThreads: 1}},
+		{SecurityMedium, ProfileCPUFavor, Params{SaltSize: 24, KeySize: 32, NonceSize: 12, ArgonTime: 4, ArgonMem: 128 * 1024, ArgonThreads: 2}},
+		{SecurityExtreme, ProfileCPUHeavy, Params{SaltSize: 32, KeySize: 32, NonceSize: 12, ArgonTime: 6, ArgonMem: 1024 * 1024, ArgonThreads: 4}},
+	}
+	for _, tt := range tests {
+		t.Run(benchName(tt.level, tt.profile), func(t *testing.T) {
+			got, err := ResolveParams(tt.level, tt.profile)
+			if err != nil {
+				t.Fatalf("ResolveParams failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := ResolveParams(SecurityLevel(42), ProfileBalanced); err == nil {
+		t.Error("ResolveParams should fail for an unknown level, but did not")
+	}
+}
+
+func TestEmptyPassphrase(t *testing.T) {
+	_, err := NewWithLevelProfile("", SecurityUltraFast, ProfileBalanced)
+	if !errors.Is(err, ErrEmptyPassphrase) {
+		t.Errorf("Expected ErrEmptyPassphrase, got %v", err)
+	}
+
+	client, err := NewWithLevelProfile("", SecurityUltraFast, ProfileBalanced, WithAllowEmptyPassphrase())
+	if err != nil {
+		t.Fatalf("Failed to create client with WithAllowEmptyPassphrase: %v", err)
+	}
+	ciphertext, err := client.Encrypt("obfuscated")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	decrypted, err := client.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+	if decrypted != "obfuscated" {
+		t.Errorf("Expected decrypted to be %q, got %q", "obfuscated", decrypted)
+	}
+}
+
+func TestPepper(t *testing.T) {
+	pepper := []byte("global-secret-pepper")
+	peppered, err := NewWithLevelProfile("PepperSecret", SecurityUltraFast, ProfileBalanced, WithPepper(pepper))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	plain, err := NewWithLevelProfile("PepperSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	otherPepper, err := NewWithLevelProfile("PepperSecret", SecurityUltraFast, ProfileBalanced, WithPepper([]byte("another-pepper")))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ciphertext, err := peppered.Encrypt("needs the pepper")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if _, err := peppered.Decrypt(ciphertext); err != nil {
+		t.Errorf("Decryption with the pepper failed: %v", err)
+	}
+	if _, err := plain.Decrypt(ciphertext); err == nil {
+		t.Error("Decryption without the pepper should fail, but did not")
+	}
+	if _, err := otherPepper.Decrypt(ciphertext); err == nil {
+		t.Error("Decryption with a different pepper should fail, but did not")
+	}
+}
+
+func TestEncryptDecryptEmptyPlaintext(t *testing.T) {
+	client, err := NewWithLevelProfile("EmptyInput", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ciphertext, err := client.EncryptRaw([]byte{})
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+	if want := headerSize + client.params.SaltSize + client.params.NonceSize + gcmTagSize; len(ciphertext) != want {
+		t.Errorf("Expected %d bytes for empty plaintext, got %d", want, len(ciphertext))
+	}
+	plain2, err := client.DecryptRaw(ciphertext)
+	if err != nil {
+		t.Fatalf("DecryptRaw failed: %v", err)
+	}
+	if len(plain2) != 0 {
+		t.Errorf("Expected empty plaintext, got %v", plain2)
+	}
+
+	encrypted, err := client.Encrypt("")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	decrypted, err := client.Decrypt(encrypted)
+	if err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+	if decrypted != "" {
+		t.Errorf("Expected empty string, got %q", decrypted)
+	}
+}
+
+func TestDecryptRawTooShort(t *testing.T) {
+	client, err := NewWithLevelProfile("ShortInput", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	ciphertext, err := client.EncryptRaw(nil)
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+
+	// Salt and nonce are present, but the tag is missing.
+	_, err = client.DecryptRaw(ciphertext[:len(ciphertext)-gcmTagSize])
+	if !errors.Is(err, ErrInvalidData) {
+		t.Errorf("Expected ErrInvalidData for a blob without tag, got %v", err)
+	}
+	_, err = client.DecryptRaw(nil)
+	if !errors.Is(err, ErrInvalidData) {
+		t.Errorf("Expected ErrInvalidData for empty input, got %v", err)
+	}
+}
+
+func TestCiphertextLen(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithDerivedNonce()}, {WithTagSize(12)}, {WithCipher(CipherXChaCha20Poly1305)}, {WithPlaintextChecksum()}} {
+		client, err := NewWithLevelProfile("LengthSecret", SecurityUltraFast, ProfileBalanced, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		for _, size := range []int{0, 1, 17, 1000} {
+			plaintext := strings.Repeat("x", size)
+			raw, err := client.EncryptRaw([]byte(plaintext))
+			if err != nil {
+				t.Fatalf("EncryptRaw failed: %v", err)
+			}
+			if got := client.CiphertextLen(size); got != len(raw) {
+				t.Errorf("CiphertextLen(%d) = %d, actual %d", size, got, len(raw))
+			}
+			if len(raw) != size+client.Overhead() {
+				t.Errorf("%d-byte plaintext: expected %d-byte overhead, got %d", size, client.Overhead(), len(raw)-size)
+			}
+			encoded, err := client.Encrypt(plaintext)
+			if err != nil {
+				t.Fatalf("Encrypt failed: %v", err)
+			}
+			if got := client.Base64Len(size); got != len(encoded) {
+				t.Errorf("Base64Len(%d) = %d, actual %d", size, got, len(encoded))
+			}
+		}
+	}
+}
+
+func TestBadKeyLengthIsWrapped(t *testing.T) {
+	client, err := NewWithLevelProfile("KeySecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	// Bypass validate to simulate a custom parameter set with an invalid AES key length.
+	client.params.KeySize = 20
+
+	_, err = client.EncryptRaw([]byte("data"))
+	if !errors.Is(err, ErrKeyDerivation) {
+		t.Fatalf("Expected ErrKeyDerivation, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "20-byte key") {
+		t.Errorf("Expected the error to mention the key length, got %q", err)
+	}
+}
+
+func TestWithSaltReader(t *testing.T) {
+	salt := bytes.Repeat([]byte{0x5a}, 16)
+	client, err := NewWithLevelProfile("SaltSecret", SecurityUltraFast, ProfileBalanced, WithSaltReader(bytes.NewReader(salt)))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	data, err := client.EncryptRaw([]byte("salted"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+	if stored := data[headerSize : headerSize+len(salt)]; !bytes.Equal(stored, salt) {
+		t.Errorf("Expected stored salt %x, got %x", salt, stored)
+	}
+	if plaintext, err := client.DecryptRaw(data); err != nil || string(plaintext) != "salted" {
+		t.Errorf("DecryptRaw failed: %q, %v", plaintext, err)
+	}
+
+	// The exhausted salt reader must not be replaced by the nonce source.
+	if _, err := client.EncryptRaw([]byte("salted")); err == nil {
+		t.Error("EncryptRaw should fail once the salt reader is exhausted, but did not")
+	}
+	if _, err := NewWithLevelProfile("SaltSecret", SecurityUltraFast, ProfileBalanced, WithRand(nil)); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Expected ErrInvalidParams for a nil random source, got %v", err)
+	}
+}
+
+func TestConfigFingerprint(t *testing.T) {
+	newClient := func(passphrase string, level SecurityLevel, opts ...Option) *Client {
+		t.Helper()
+		client, err := NewWithLevelProfile(passphrase, level, ProfileBalanced, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		return client
+	}
+	base := newClient("PassphraseOne", SecurityUltraFast).ConfigFingerprint()
+
+	if got := newClient("PassphraseTwo", SecurityUltraFast).ConfigFingerprint(); got != base {
+		t.Errorf("Fingerprint should not depend on the passphrase: %s != %s", got, base)
+	}
+	for name, client := range map[string]*Client{
+		"level":       newClient("PassphraseOne", SecurityStandard),
+		"cipher":      newClient("PassphraseOne", SecurityUltraFast, WithCipher(CipherXChaCha20Poly1305)),
+		"tag":         newClient("PassphraseOne", SecurityUltraFast, WithTagSize(12)),
+		"nonce":       newClient("PassphraseOne", SecurityUltraFast, WithDerivedNonce()),
+		"pepper":      newClient("PassphraseOne", SecurityUltraFast, WithPepper([]byte("pepper"))),
+		"default AAD": newClient("PassphraseOne", SecurityUltraFast, WithDefaultAAD([]byte("tenant-a"))),
+		"prefix":      newClient("PassphraseOne", SecurityUltraFast, WithOutputPrefix("enc:")),
+		"base64":      newClient("PassphraseOne", SecurityUltraFast, WithBase64Encoding(base64.URLEncoding)),
+		"checksum":    newClient("PassphraseOne", SecurityUltraFast, WithPlaintextChecksum()),
+	} {
+		if client.ConfigFingerprint() == base {
+			t.Errorf("Changing the %s should change the fingerprint", name)
+		}
+	}
+}
+
+func TestSameParams(t *testing.T) {
+	a, err := NewWithLevelProfile("Secret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	b, err := NewWithLevelProfile("Other secret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if !a.SameParams(b) || a.Diff(b) != "" {
+		t.Errorf("Clients differing only by passphrase: SameParams = %v, Diff = %q", a.SameParams(b), a.Diff(b))
+	}
+
+	c, err := NewWithLevelProfile("Secret", SecurityUltraFast, ProfileRAMHeavy, WithCipher(CipherXChaCha20Poly1305))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if a.SameParams(c) {
+		t.Error("SameParams should be false for different profiles and ciphers")
+	}
+	want := "ArgonTime: 2 != 1; ArgonMem: 19456 != 47104; NonceSize: 12 != 24; Cipher: AES-GCM != XChaCha20-Poly1305"
+	if got := a.Diff(c); got != want {
+		t.Errorf("Diff = %q, want %q", got, want)
+	}
+
+	implicit, err := NewWithLevelProfile("Secret", SecurityUltraFast, ProfileBalanced, WithImplicitParams())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if a.SameParams(implicit) {
+		t.Error("SameParams should be false when only one client has implicit params")
+	}
+	if got, want := a.Diff(implicit), "ImplicitParams: false != true"; got != want {
+		t.Errorf("Diff = %q, want %q", got, want)
+	}
+
+	// Blobs of clients with different default AADs do not decrypt each other.
+	tenantA, err := NewWithLevelProfile("Secret", SecurityUltraFast, ProfileBalanced, WithDefaultAAD([]byte("tenant-a")))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	tenantB, err := NewWithLevelProfile("Secret", SecurityUltraFast, ProfileBalanced, WithDefaultAAD([]byte("tenant-b")))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if tenantA.SameParams(tenantB) || tenantA.ConfigFingerprint() == tenantB.ConfigFingerprint() {
+		t.Error("SameParams and ConfigFingerprint should tell different default AADs apart")
+	}
+	if got := tenantA.Diff(tenantB); !strings.HasPrefix(got, "DefaultAAD: ") || strings.Contains(got, "tenant") {
+		t.Errorf("Diff = %q, want a DefaultAAD digest difference", got)
+	}
+}
+
+func TestEncryptRawInto(t *testing.T) {
+	client, err := NewWithLevelProfile("IntoSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	prefix := []byte("prefix:")
+	buf := make([]byte, len(prefix), len(prefix)+client.CiphertextLen(6))
+	copy(buf, prefix)
+
+	out, err := client.EncryptRawInto(buf, []byte("reused"))
+	if err != nil {
+		t.Fatalf("EncryptRawInto failed: %v", err)
+	}
+	if &out[0] != &buf[0] {
+		t.Error("EncryptRawInto should reuse a large enough dst")
+	}
+	if !bytes.HasPrefix(out, prefix) {
+		t.Errorf("EncryptRawInto should keep the existing content of dst, got %q", out[:len(prefix)])
+	}
+	plaintext, err := client.DecryptRaw(out[len(prefix):])
+	if err != nil || string(plaintext) != "reused" {
+		t.Errorf("DecryptRaw failed: %q, %v", plaintext, err)
+	}
+}
+
+func TestDecryptIgnoresWhitespace(t *testing.T) {
+	client, err := NewWithLevelProfile("SpaceSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	encrypted, err := client.Encrypt("copy-pasted")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	for _, input := range []string{
+		encrypted + "\n",
+		"  " + encrypted + "\r\n",
+		"\t" + encrypted[:20] + "\n" + encrypted[20:40] + "\r\n" + encrypted[40:] + " ",
+	} {
+		plaintext, err := client.Decrypt(input)
+		if err != nil {
+			t.Fatalf("Decrypt(%q) failed: %v", input, err)
+		}
+		if plaintext != "copy-pasted" {
+			t.Errorf("Expected %q, got %q", "copy-pasted", plaintext)
+		}
+	}
+}
+
+func TestEstimatedCost(t *testing.T) {
+	var prev time.Duration
+	for _, level := range allSecurityLevels {
+		params, err := ResolveParams(level, ProfileBalanced)
+		if err != nil {
+			t.Fatalf("ResolveParams(%v) failed: %v", level, err)
+		}
+		mem, approx := params.EstimatedCost()
+		if mem != uint64(params.ArgonMem)*1024 {
+			t.Errorf("%v: expected %d bytes, got %d", level, uint64(params.ArgonMem)*1024, mem)
+		}
+		if approx <= prev {
+			t.Errorf("%v: estimated time %v should exceed the previous level's %v", level, approx, prev)
+		}
+		prev = approx
+	}
+}
+
+func TestReconfigure(t *testing.T) {
+	client, err := NewWithLevelProfile("ReconfigureSecret", SecurityStandard, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	old, err := client.EncryptRaw([]byte("before the policy update"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+
+	if err := client.Reconfigure(SecurityHigh, ProfileBalanced); err != nil {
+		t.Fatalf("Reconfigure failed: %v", err)
+	}
+	data, err := client.EncryptRaw([]byte("after the policy update"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+	want, _ := ResolveParams(SecurityHigh, ProfileBalanced)
+	h, _, err := readHeader(data)
+	if err != nil {
+		t.Fatalf("readHeader failed: %v", err)
+	}
+	if h.params != want {
+		t.Errorf("Expected new blobs to use %+v, got %+v", want, h.params)
+	}
+	if plaintext, err := client.DecryptRaw(old); err != nil || string(plaintext) != "before the policy update" {
+		t.Errorf("DecryptRaw of a blob from before Reconfigure failed: %q, %v", plaintext, err)
+	}
+
+	if err := client.Reconfigure(SecurityLevel(42), ProfileBalanced); err == nil {
+		t.Error("Reconfigure with an unknown level should fail, but did not")
+	}
+	if client.currentParams() != want {
+		t.Error("A failed Reconfigure should leave the parameters unchanged")
+	}
+}
+
+func TestReconfigureStreams(t *testing.T) {
+	for _, implicit := range []bool{false, true} {
+		var opts []Option
+		if implicit {
+			opts = append(opts, WithImplicitParams())
+		}
+		client, err := NewWithLevelProfile("ReconfigureSecret", SecurityUltraFast, ProfileBalanced, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		var old bytes.Buffer
+		if err := client.EncryptStream(&old, bytes.NewReader([]byte("streamed before the update"))); err != nil {
+			t.Fatalf("EncryptStream failed: %v", err)
+		}
+		if err := client.Reconfigure(SecurityStandard, ProfileBalanced); err != nil {
+			t.Fatalf("Reconfigure failed: %v", err)
+		}
+
+		var out bytes.Buffer
+		err = client.DecryptStream(&out, bytes.NewReader(old.Bytes()))
+		switch {
+		case implicit && err == nil:
+			t.Error("Stream without recorded costs should not decrypt after Reconfigure")
+		case !implicit && (err != nil || out.String() != "streamed before the update"):
+			t.Errorf("DecryptStream of a stream from before Reconfigure = %q, %v", out.String(), err)
+		}
+	}
+}
+
+func TestDeriveKeyForExternal(t *testing.T) {
+	client, err := NewWithLevelProfile("ExternalSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	salt := bytes.Repeat([]byte{0x42}, 16)
+	key1, err := client.DeriveKeyForExternal(salt, 64)
+	if err != nil {
+		t.Fatalf("DeriveKeyForExternal failed: %v", err)
+	}
+	if len(key1) != 64 {
+		t.Fatalf("Expected a 64-byte key, got %d bytes", len(key1))
+	}
+	key2, err := client.DeriveKeyForExternal(salt, 64)
+	if err != nil {
+		t.Fatalf("DeriveKeyForExternal failed: %v", err)
+	}
+	if !bytes.Equal(key1, key2) {
+		t.Error("DeriveKeyForExternal should be deterministic for a fixed salt")
+	}
+	other, err := client.DeriveKeyForExternal(bytes.Repeat([]byte{0x43}, 16), 64)
+	if err != nil {
+		t.Fatalf("DeriveKeyForExternal failed: %v", err)
+	}
+	if bytes.Equal(key1, other) {
+		t.Error("Different salts should derive different keys")
+	}
+
+	if _, err := client.DeriveKeyForExternal(salt[:8], 32); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Expected ErrInvalidParams for a short salt, got %v", err)
+	}
+	if _, err := client.DeriveKeyForExternal(salt, 2); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Expected ErrInvalidParams for a 2-byte key, got %v", err)
+	}
+	if _, err := client.DeriveKeyForExternal(salt, math.MaxUint32); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Expected ErrInvalidParams for a 4 GiB key, got %v", err)
+	}
+}
+
+func TestDeriveKeyForExternalSeparatedFromBlobKeys(t *testing.T) {
+	client, err := NewWithLevelProfile("ExternalSecret", SecurityUltraFast, ProfileBalanced, WithKeyCache(4))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	blob, err := client.EncryptRaw([]byte("blob"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+	h, rest, err := readHeader(blob)
+	if err != nil {
+		t.Fatalf("readHeader failed: %v", err)
+	}
+	salt := rest[:h.params.SaltSize]
+	blobKey, err := client.deriveKey(salt, h.params)
+	if err != nil {
+		t.Fatalf("deriveKey failed: %v", err)
+	}
+
+	derivations := client.derivations.Load()
+	external, err := client.DeriveKeyForExternal(salt, h.params.KeySize)
+	if err != nil {
+		t.Fatalf("DeriveKeyForExternal failed: %v", err)
+	}
+	if len(external) != 32 || bytes.Equal(external, blobKey) {
+		t.Error("A 32-byte external key must differ from the blob key for the same salt")
+	}
+	if client.derivations.Load() != derivations+1 {
+		t.Error("DeriveKeyForExternal should run Argon2 instead of using the key cache")
+	}
+	if _, ok := client.keyCache.get(append(externalKeyContext, salt...), h.params); ok {
+		t.Error("External keys should not be cached")
+	}
+}
+
+// zeroReader simulates a broken random source.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func TestWeakRandomness(t *testing.T) {
+	for name, opt := range map[string]Option{
+		"salt":  WithSaltReader(zeroReader{}),
+		"nonce": WithRand(zeroReader{}),
+	} {
+		client, err := NewWithLevelProfile("RandomSecret", SecurityUltraFast, ProfileBalanced, opt)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		if _, err := client.EncryptRaw([]byte("data")); !errors.Is(err, ErrWeakRandomness) {
+			t.Errorf("%s: expected ErrWeakRandomness from EncryptRaw, got %v", name, err)
+		}
+		if err := client.EncryptStream(&bytes.Buffer{}, strings.NewReader("data")); !errors.Is(err, ErrWeakRandomness) {
+			t.Errorf("%s: expected ErrWeakRandomness from EncryptStream, got %v", name, err)
+		}
+	}
+}
+
+// flakyReader fails the first failures reads, then reads from crypto/rand.
+type flakyReader struct {
+	failures int
+}
+
+func (r *flakyReader) Read(p []byte) (int, error) {
+	if r.failures > 0 {
+		r.failures--
+		return 0, errors.New("entropy temporarily unavailable")
+	}
+	return rand.Read(p)
+}
+
+func TestRandomRetry(t *testing.T) {
+	client, err := NewWithLevelProfile("RetrySecret", SecurityUltraFast, ProfileBalanced, WithRand(&flakyReader{failures: 2}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	encrypted, err := client.EncryptRaw([]byte("data"))
+	if err != nil {
+		t.Fatalf("EncryptRaw should succeed after two failed reads, got %v", err)
+	}
+	if plaintext, err := client.DecryptRaw(encrypted); err != nil || string(plaintext) != "data" {
+		t.Errorf("DecryptRaw failed: %q, %v", plaintext, err)
+	}
+
+	client, err = NewWithLevelProfile("RetrySecret", SecurityUltraFast, ProfileBalanced, WithRand(&flakyReader{failures: randomAttempts}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := client.EncryptRaw([]byte("data")); !errors.Is(err, ErrRandomnessUnavailable) {
+		t.Errorf("Expected ErrRandomnessUnavailable once every attempt fails, got %v", err)
+	}
+}
+
+func TestEntropyCheck(t *testing.T) {
+	errPattern := errors.New("repeated byte pattern")
+	rejectRepeats := func(b []byte) error {
+		if bytes.Count(b, b[:1]) == len(b) {
+			return errPattern
+		}
+		return nil
+	}
+	crafted := bytes.Repeat([]byte{0xAB}, 64)
+	client, err := NewWithLevelProfile("EntropySecret", SecurityUltraFast, ProfileBalanced,
+		WithEntropyCheck(rejectRepeats), WithSaltReader(bytes.NewReader(crafted)))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	_, err = client.EncryptRaw([]byte("data"))
+	if !errors.Is(err, ErrWeakRandomness) || !errors.Is(err, errPattern) {
+		t.Errorf("Expected the entropy check error from EncryptRaw, got %v", err)
+	}
+
+	healthy, err := NewWithLevelProfile("EntropySecret", SecurityUltraFast, ProfileBalanced, WithEntropyCheck(rejectRepeats))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := healthy.EncryptRaw([]byte("data")); err != nil {
+		t.Errorf("EncryptRaw with a healthy source failed: %v", err)
+	}
+}
+
+func TestNewDefaults(t *testing.T) {
+	client, err := New("DefaultSecret")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	want, _ := ResolveParams(SecurityStandard, ProfileBalanced)
+	if client.params != want {
+		t.Errorf("Expected default params %+v, got %+v", want, client.params)
+	}
+	if client.cipher != CipherAESGCM || client.tagSize != gcmTagSize {
+		t.Errorf("Unexpected default cipher %v with a %d-byte tag", client.cipher, client.tagSize)
+	}
+}
+
+func TestNewOptions(t *testing.T) {
+	client, err := New("OptionSecret",
+		WithLevel(SecurityUltraFast),
+		WithProfile(ProfileCPUHeavy),
+		WithCipher(CipherXChaCha20Poly1305),
+		WithDerivedNonce(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	want, _ := ResolveParams(SecurityUltraFast, ProfileCPUHeavy)
+	want.NonceSize = 24
+	if client.params != want {
+		t.Errorf("Expected params %+v, got %+v", want, client.params)
+	}
+	data, err := client.EncryptRaw([]byte("wired"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+	_, info, err := client.DecryptRawWithInfo(data)
+	if err != nil {
+		t.Fatalf("DecryptRawWithInfo failed: %v", err)
+	}
+	if info.Cipher != CipherXChaCha20Poly1305 || !info.DerivedNonce || info.Params != want {
+		t.Errorf("Options not reflected in the blob: %+v", info)
+	}
+
+	legacy, err := NewWithLevelProfile("OptionSecret", SecurityUltraFast, ProfileCPUHeavy)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if want, _ := ResolveParams(SecurityUltraFast, ProfileCPUHeavy); legacy.params != want {
+		t.Errorf("NewWithLevelProfile: expected %+v, got %+v", want, legacy.params)
+	}
+
+	if _, err := New("OptionSecret", WithLevel(SecurityLevel(42))); err == nil {
+		t.Error("New with an unknown level should fail, but did not")
+	}
+	if _, err := New("OptionSecret", WithProfile(Argon2Profile(42))); err == nil {
+		t.Error("New with an unknown profile should fail, but did not")
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client, err := NewWithLevelProfile("LoggedSecret", SecurityUltraFast, ProfileBalanced, WithLogger(logger))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	data, err := client.EncryptRaw([]byte("never logged"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+	data[len(data)-1] ^= 1
+	if _, err := client.DecryptRaw(data); err == nil {
+		t.Fatal("DecryptRaw of a tampered blob should fail, but did not")
+	}
+
+	out := logs.String()
+	for _, want := range []string{"key derivation finished", "argon_mem_kib=19456", "decryption failed authentication"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in the logs:\n%s", want, out)
+		}
+	}
+	for _, secret := range []string{"LoggedSecret", "never logged"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("Logs leak %q:\n%s", secret, out)
+		}
+	}
+}
+
+func TestSaltContext(t *testing.T) {
+	billing, err := NewWithLevelProfile("SharedSecret", SecurityUltraFast, ProfileBalanced, WithSaltContext([]byte("billing")))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	reports, err := NewWithLevelProfile("SharedSecret", SecurityUltraFast, ProfileBalanced, WithSaltContext([]byte("reports")))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	plain, err := NewWithLevelProfile("SharedSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	encrypted, err := billing.EncryptRaw([]byte("invoice 42"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+	if len(encrypted) != plain.CiphertextLen(len("invoice 42")) {
+		t.Errorf("The salt context should not be stored, blob is %d bytes", len(encrypted))
+	}
+	if decrypted, err := billing.DecryptRaw(encrypted); err != nil || string(decrypted) != "invoice 42" {
+		t.Errorf("DecryptRaw with the same context failed: %q, %v", decrypted, err)
+	}
+	if _, err := reports.DecryptRaw(encrypted); err == nil {
+		t.Error("DecryptRaw with another salt context should fail, but did not")
+	}
+	if _, err := plain.DecryptRaw(encrypted); err == nil {
+		t.Error("DecryptRaw without the salt context should fail, but did not")
+	}
+}
+
+func TestEncryptTimed(t *testing.T) {
+	client, err := NewWithLevelProfile("TimedSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	encrypted, elapsed, err := client.EncryptTimed("timed")
+	if err != nil {
+		t.Fatalf("EncryptTimed failed: %v", err)
+	}
+	if elapsed <= 0 {
+		t.Errorf("Expected a positive duration, got %v", elapsed)
+	}
+	if decrypted, err := client.Decrypt(encrypted); err != nil || decrypted != "timed" {
+		t.Errorf("Decrypt failed: %q, %v", decrypted, err)
+	}
+}
+
+func TestWithoutBase64Padding(t *testing.T) {
+	padded, err := NewWithLevelProfile("PaddingSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	unpadded, err := NewWithLevelProfile("PaddingSecret", SecurityUltraFast, ProfileBalanced, WithoutBase64Padding())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// Three consecutive lengths cover every base64 remainder, padded or not.
+	for _, plaintext := range []string{"a", "ab", "abc"} {
+		withPadding, err := padded.Encrypt(plaintext)
+		if err != nil {
+			t.Fatalf("Encrypt failed: %v", err)
+		}
+		withoutPadding, err := unpadded.Encrypt(plaintext)
+		if err != nil {
+			t.Fatalf("Encrypt failed: %v", err)
+		}
+		if strings.Contains(withoutPadding, "=") {
+			t.Errorf("Unpadded output %q contains padding", withoutPadding)
+		}
+		if len(withoutPadding) != unpadded.Base64Len(len(plaintext)) {
+			t.Errorf("Base64Len = %d, output is %d characters", unpadded.Base64Len(len(plaintext)), len(withoutPadding))
+		}
+
+		for _, encrypted := range []string{withPadding, withoutPadding} {
+			for _, client := range []*Client{padded, unpadded} {
+				if decrypted, err := client.Decrypt(encrypted); err != nil || decrypted != plaintext {
+					t.Errorf("Decrypt(%q) = %q, %v; want %q", encrypted, decrypted, err, plaintext)
+				}
+			}
+		}
+	}
+}
+
+func TestAllLevelsAndProfiles(t *testing.T) {
+	levels := AllSecurityLevels()
+	if len(levels) != len(securityLevels) {
+		t.Errorf("AllSecurityLevels returned %d levels, %d are defined", len(levels), len(securityLevels))
+	}
+	profiles := AllProfiles()
+	if len(profiles) != len(argon2Profiles) {
+		t.Errorf("AllProfiles returned %d profiles, %d are defined", len(profiles), len(argon2Profiles))
+	}
+
+	for _, level := range levels {
+		for _, profile := range profiles {
+			p, err := ResolveParams(level, profile)
+			if err != nil {
+				t.Fatalf("ResolveParams(%v, %v) failed: %v", level, profile, err)
+			}
+			desc := Describe(level, profile)
+			if !strings.HasPrefix(desc, level.String()+"/"+profile.String()) {
+				t.Errorf("Describe(%v, %v) = %q, should start with the names", level, profile, desc)
+			}
+			if mem := fmt.Sprintf("m=%d MiB", p.ArgonMem/1024); !strings.Contains(desc, mem) {
+				t.Errorf("Describe(%v, %v) = %q, should mention %q", level, profile, desc, mem)
+			}
+		}
+	}
+}
+
+func FuzzDecryptRaw(f *testing.F) {
+	client, err := NewWithLevelProfile("FuzzSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		f.Fatalf("Failed to create client: %v", err)
+	}
+	valid, err := client.EncryptRaw([]byte("fuzz seed"))
+	if err != nil {
+		f.Fatalf("EncryptRaw failed: %v", err)
+	}
+	padded, err := NewWithLevelProfile("FuzzSecret", SecurityUltraFast, ProfileBalanced, WithRandomPadding(0, 8), WithDerivedNonce())
+	if err != nil {
+		f.Fatalf("Failed to create client: %v", err)
+	}
+	paddedBlob, err := padded.EncryptRaw([]byte("fuzz seed"))
+	if err != nil {
+		f.Fatalf("EncryptRaw failed: %v", err)
+	}
+	f.Add(valid)
+	f.Add(paddedBlob)
+	f.Add(valid[:headerSize])
+	f.Add([]byte{})
+	f.Add([]byte("CRYP"))
+
+	// Headers may request up to 4 GiB of Argon2 memory; a memory limit makes
+	// the guard refuse those instead of exhausting the fuzzing machine.
+	defer debug.SetMemoryLimit(debug.SetMemoryLimit(512 << 20))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		plaintext, err := client.DecryptRaw(data)
+		if err == nil {
+			// Only the seeds authenticate; fuzzing workers encrypt their own.
+			if string(plaintext) != "fuzz seed" {
+				t.Errorf("DecryptRaw accepted a forged blob: %q", plaintext)
+			}
+			return
+		}
+		for _, typed := range []error{ErrInvalidData, ErrDecryptFailed, ErrInvalidParams, ErrArgon2VersionMismatch, ErrInsufficientMemory, ErrKeyDerivation} {
+			if errors.Is(err, typed) {
+				return
+			}
+		}
+		t.Errorf("DecryptRaw returned an untyped error: %v", err)
+	})
+}
+
+func TestWithOutputPrefix(t *testing.T) {
+	acme, err := NewWithLevelProfile("TenantSecret", SecurityUltraFast, ProfileBalanced, WithOutputPrefix("acme:"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	globex, err := NewWithLevelProfile("TenantSecret", SecurityUltraFast, ProfileBalanced, WithOutputPrefix("globex:"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	encrypted, err := acme.Encrypt("tenant data")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if !strings.HasPrefix(encrypted, "acme:") {
+		t.Errorf("Expected the output to start with the prefix, got %q", encrypted)
+	}
+	if len(encrypted) != acme.Base64Len(len("tenant data")) {
+		t.Errorf("Base64Len = %d, output is %d characters", acme.Base64Len(len("tenant data")), len(encrypted))
+	}
+	if decrypted, err := acme.Decrypt(encrypted + "\n"); err != nil || decrypted != "tenant data" {
+		t.Errorf("Decrypt failed: %q, %v", decrypted, err)
+	}
+
+	if _, err := globex.Decrypt(encrypted); !errors.Is(err, ErrWrongPrefix) {
+		t.Errorf("Expected ErrWrongPrefix for another tenant's prefix, got %v", err)
+	}
+	if _, err := acme.Decrypt(strings.TrimPrefix(encrypted, "acme:")); !errors.Is(err, ErrWrongPrefix) {
+		t.Errorf("Expected ErrWrongPrefix without a prefix, got %v", err)
+	}
+}
+
+func TestWithBase64Encoding(t *testing.T) {
+	// The standard alphabet rotated by one: valid characters, different values.
+	alphabet := "BCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/A"
+	custom := base64.NewEncoding(alphabet)
+	client, err := NewWithLevelProfile("AlphabetSecret", SecurityUltraFast, ProfileBalanced, WithBase64Encoding(custom))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	standard, err := NewWithLevelProfile("AlphabetSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	encrypted, err := client.Encrypt("custom alphabet")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if _, err := custom.DecodeString(encrypted); err != nil {
+		t.Errorf("Output is not in the custom alphabet: %v", err)
+	}
+	if decrypted, err := client.Decrypt(encrypted); err != nil || decrypted != "custom alphabet" {
+		t.Errorf("Decrypt failed: %q, %v", decrypted, err)
+	}
+	if _, err := standard.Decrypt(encrypted); err == nil {
+		t.Error("The standard decoder should fail on the custom alphabet, but did not")
+	}
+
+	if _, err := NewWithLevelProfile("AlphabetSecret", SecurityUltraFast, ProfileBalanced, WithBase64Encoding(nil)); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Expected ErrInvalidParams for a nil encoding, got %v", err)
+	}
+}
+
+func TestValidateParams(t *testing.T) {
+	for _, level := range AllSecurityLevels() {
+		for _, profile := range AllProfiles() {
+			if err := ValidateParams(level, profile); err != nil {
+				t.Errorf("ValidateParams(%v, %v) failed: %v", level, profile, err)
+			}
+		}
+	}
+	if err := ValidateParams(SecurityLevel(99), ProfileBalanced); err == nil {
+		t.Error("ValidateParams should reject an unknown security level, but did not")
+	}
+	if err := ValidateParams(SecurityStandard, Argon2Profile(-1)); err == nil {
+		t.Error("ValidateParams should reject an unknown profile, but did not")
+	}
+}
+
+func TestDecryptRawInto(t *testing.T) {
+	client, err := NewWithLevelProfile("IntoSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	blob, err := client.EncryptRaw([]byte("decrypted in place"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+
+	dst := make([]byte, 5, 64)
+	plaintext, err := client.DecryptRawInto(dst, blob)
+	if err != nil {
+		t.Fatalf("DecryptRawInto failed: %v", err)
+	}
+	if string(plaintext) != "decrypted in place" {
+		t.Errorf("Unexpected plaintext %q", plaintext)
+	}
+	if &plaintext[0] != &dst[:1][0] {
+		t.Error("DecryptRawInto should reuse the capacity of dst")
+	}
+
+	// Without enough capacity, a new buffer is allocated.
+	plaintext, err = client.DecryptRawInto(nil, blob)
+	if err != nil || string(plaintext) != "decrypted in place" {
+		t.Errorf("DecryptRawInto(nil) = %q, %v", plaintext, err)
+	}
+	blob[len(blob)-1] ^= 0x01
+	if _, err := client.DecryptRawInto(dst, blob); !errors.Is(err, ErrDecryptFailed) {
+		t.Errorf("Expected ErrDecryptFailed for tampered data, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package cryptio
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// EncryptDataURI encrypts plaintext and returns the blob as an RFC 2397 data URI,
+// data:<mediaType>;base64,<blob>, for HTML, CSS or JSON fields expecting one.
+// mediaType may be empty; it is not authenticated.
+func (c *Client) EncryptDataURI(plaintext []byte, mediaType string) (string, error) {
+	if mediaType != "" {
+		if _, _, err := mime.ParseMediaType(mediaType); err != nil {
+			return "", fmt.Errorf("%w: media type %q: %v", ErrInvalidParams, mediaType, err)
+		}
+	}
+	raw, err := c.EncryptRaw(plaintext)
+	if err != nil {
+		return "", err
+	}
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(raw), nil
+}
+
+// DecryptDataURI decrypts a data URI produced by EncryptDataURI and returns the
+// plaintext with the URI's media type.
+func (c *Client) DecryptDataURI(uri string) ([]byte, string, error) {
+	rest, ok := strings.CutPrefix(uri, "data:")
+	if !ok {
+		return nil, "", fmt.Errorf("%w: not a data URI", ErrInvalidData)
+	}
+	meta, encoded, ok := strings.Cut(rest, ",")
+	if !ok {
+		return nil, "", fmt.Errorf("%w: data URI has no data", ErrInvalidData)
+	}
+	mediaType, ok := strings.CutSuffix(meta, ";base64")
+	if !ok {
+		return nil, "", fmt.Errorf("%w: data URI is not base64", ErrInvalidData)
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidData, err)
+	}
+	plaintext, err := c.DecryptRaw(raw)
+	if err != nil {
+		return nil, "", err
+	}
+	return plaintext, mediaType, nil
+}
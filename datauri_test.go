@@ -0,0 +1,41 @@
+package cryptio
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncryptDataURI(t *testing.T) {
+	client, err := NewWithLevelProfile("DataURISecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	uri, err := client.EncryptDataURI([]byte(`{"token":"abc"}`), "application/json")
+	if err != nil {
+		t.Fatalf("EncryptDataURI failed: %v", err)
+	}
+	if !strings.HasPrefix(uri, "data:application/json;base64,") {
+		t.Errorf("Unexpected data URI prefix: %q", uri)
+	}
+	plaintext, mediaType, err := client.DecryptDataURI(uri)
+	if err != nil {
+		t.Fatalf("DecryptDataURI failed: %v", err)
+	}
+	if string(plaintext) != `{"token":"abc"}` || mediaType != "application/json" {
+		t.Errorf("Expected the JSON plaintext and media type, got %q, %q", plaintext, mediaType)
+	}
+
+	for name, bad := range map[string]string{
+		"scheme":  strings.Replace(uri, "data:", "http:", 1),
+		"base64":  strings.Replace(uri, ";base64", "", 1),
+		"no data": "data:application/json;base64",
+	} {
+		if _, _, err := client.DecryptDataURI(bad); !errors.Is(err, ErrInvalidData) {
+			t.Errorf("%s: expected ErrInvalidData, got %v", name, err)
+		}
+	}
+	if _, err := client.EncryptDataURI([]byte("x"), "not a media type"); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Expected ErrInvalidParams for an invalid media type, got %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package cryptio
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// maxSyntheticNonce is the longest nonce syntheticNonce can produce (one HMAC-SHA256).
+const maxSyntheticNonce = 32
+
+// syntheticNonce derives the nonce of a deterministic blob from the key, the
+// additional data and the plaintext, as in SIV constructions: equal inputs give
+// equal nonces, distinct inputs give unrelated ones.
+func (c *Client) syntheticNonce(salt []byte, p Params, ad, plaintext []byte) ([]byte, error) {
+	key, err := c.deriveKey(salt, p)
+	if err != nil {
+		return nil, err
+	}
+	nonceKey := keyedHash(key, []byte("cryptio synthetic nonce"))
+	msg := binary.BigEndian.AppendUint64(nil, uint64(len(ad)))
+	msg = append(append(msg, ad...), plaintext...)
+	return keyedHash(nonceKey, msg)[:p.NonceSize], nil
+}
+
+// validateDeterministic checks WithDeterministic is combined with a namespace salt
+// and without options adding randomness.
+func (c *Client) validateDeterministic() error {
+	if !c.deterministic {
+		return nil
+	}
+	switch {
+	case c.fixedSalt == nil:
+		return errors.New("WithDeterministic requires a namespace client, see NewNamespace")
+	case c.derivedNonce:
+		return errors.New("WithDeterministic cannot be combined with WithDerivedNonce")
+	case c.padMax > 0:
+		return errors.New("WithDeterministic cannot be combined with WithRandomPadding")
+	case c.params.NonceSize > maxSyntheticNonce:
+		return fmt.Errorf("%w: deterministic nonces are at most %d bytes", ErrInvalidParams, maxSyntheticNonce)
+	}
+	return nil
+}
+
+// SameContent reports whether two blobs produced by the same deterministic client
+// (see WithDeterministic) hold the same plaintext, without decrypting them.
+// The answer is only meaningful in deterministic mode: blobs in the regular
+// random mode differ even for equal plaintexts, so they yield ErrNotDeterministic.
+func SameContent(a, b []byte) (bool, error) {
+	for _, blob := range [][]byte{a, b} {
+		h, _, err := readHeader(blob)
+		if err != nil {
+			return false, err
+		}
+		if h.flags&flagDeterministic == 0 {
+			return false, ErrNotDeterministic
+		}
+	}
+	return subtle.ConstantTimeCompare(a, b) == 1, nil
+}
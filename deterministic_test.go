@@ -0,0 +1,56 @@
+package cryptio
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestDeterministicSameContent(t *testing.T) {
+	salt := []byte("customer emails index salt")
+	client, err := NewNamespace("IndexSecret", salt, SecurityUltraFast, ProfileBalanced, WithDeterministic())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	a1, err := client.EncryptRaw([]byte("alice@example.com"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+	a2, err := client.EncryptRaw([]byte("alice@example.com"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+	b, err := client.EncryptRaw([]byte("bob@example.com"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+
+	if !bytes.Equal(a1, a2) {
+		t.Error("Deterministic encryptions of the same plaintext should be identical")
+	}
+	if same, err := SameContent(a1, a2); err != nil || !same {
+		t.Errorf("SameContent(a1, a2) = %v, %v; want true", same, err)
+	}
+	if same, err := SameContent(a1, b); err != nil || same {
+		t.Errorf("SameContent(a1, b) = %v, %v; want false", same, err)
+	}
+	if plaintext, err := client.DecryptRaw(a1); err != nil || string(plaintext) != "alice@example.com" {
+		t.Errorf("DecryptRaw failed: %q, %v", plaintext, err)
+	}
+}
+
+func TestSameContentRandomMode(t *testing.T) {
+	client, err := NewWithLevelProfile("RandomSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	a1, _ := client.EncryptRaw([]byte("same"))
+	a2, _ := client.EncryptRaw([]byte("same"))
+	if _, err := SameContent(a1, a2); !errors.Is(err, ErrNotDeterministic) {
+		t.Errorf("Expected ErrNotDeterministic for random-mode blobs, got %v", err)
+	}
+
+	if _, err := NewWithLevelProfile("RandomSecret", SecurityUltraFast, ProfileBalanced, WithDeterministic()); err == nil {
+		t.Error("WithDeterministic without a namespace salt should fail, but did not")
+	}
+}
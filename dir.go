@@ -0,0 +1,150 @@
+package cryptio
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// EncryptDir encrypts every file under srcDir with EncryptStream into the same
+// relative path under dstDir, which is created if needed. With
+// WithEncryptedFilenames, each file and directory name is encrypted too.
+//
+// Symbolic links are not followed: like devices, sockets and other files that
+// are not regular, they stop the walk with ErrUnsupportedFile, so nothing is
+// silently left out of a backup. Files are created with the permission bits of
+// their source, and directories get theirs once filled; owners and times are not
+// kept. Existing files in dstDir are never overwritten. On error, dstDir holds the
+// files completed so far.
+func (c *Client) EncryptDir(srcDir, dstDir string) error {
+	return c.copyDir(srcDir, dstDir, c.encryptName, c.EncryptStream)
+}
+
+// DecryptDir restores into dstDir a tree encrypted by EncryptDir into srcDir, with
+// the same client settings.
+func (c *Client) DecryptDir(srcDir, dstDir string) error {
+	return c.copyDir(srcDir, dstDir, c.decryptName, c.DecryptStream)
+}
+
+// copyDir mirrors srcDir into dstDir, renaming every entry with rename and
+// transforming file contents with transform.
+func (c *Client) copyDir(srcDir, dstDir string, rename func(string) (string, error), transform func(io.Writer, io.Reader) error) error {
+	absSrc, err := filepath.Abs(srcDir)
+	if err != nil {
+		return err
+	}
+	absDst, err := filepath.Abs(dstDir)
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(absSrc, absDst); err == nil && filepath.IsLocal(rel) {
+		return fmt.Errorf("%w: destination %s is inside %s", ErrInvalidParams, dstDir, srcDir)
+	}
+	if err := os.MkdirAll(dstDir, 0o700); err != nil {
+		return err
+	}
+	type dirPerm struct {
+		path string
+		perm fs.FileMode
+	}
+	var dirs []dirPerm
+	targets := map[string]string{".": dstDir}
+	err = filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		name, err := rename(d.Name())
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		target := filepath.Join(targets[filepath.Dir(rel)], name)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			targets[rel] = target
+			dirs = append(dirs, dirPerm{target, info.Mode().Perm()})
+			return os.Mkdir(target, 0o700)
+		case d.Type().IsRegular():
+			return copyFile(target, path, info.Mode().Perm(), transform)
+		default:
+			return fmt.Errorf("%w: %s is a %v", ErrUnsupportedFile, path, d.Type())
+		}
+	})
+	if err != nil {
+		return err
+	}
+	// Deepest first, so that read-only directories are filled before closing them.
+	for _, dir := range slices.Backward(dirs) {
+		if err := os.Chmod(dir.path, dir.perm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile writes the transformed contents of src to a new file dst, removing it
+// on failure.
+func copyFile(dst, src string, perm fs.FileMode, transform func(io.Writer, io.Reader) error) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if err := transform(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return fmt.Errorf("%s: %w", src, err)
+	}
+	return out.Close()
+}
+
+// encryptName returns name encrypted and base64url-encoded with
+// WithEncryptedFilenames, or name itself. Encrypted names are about 90 bytes
+// longer, so names beyond 120 bytes or so exceed common file system limits.
+func (c *Client) encryptName(name string) (string, error) {
+	if !c.encryptedNames {
+		return name, nil
+	}
+	encrypted, err := c.EncryptRaw([]byte(name))
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(encrypted), nil
+}
+
+// decryptName reverses encryptName, rejecting decrypted names that are not a
+// single path element so that a crafted tree cannot write outside dstDir.
+func (c *Client) decryptName(name string) (string, error) {
+	if !c.encryptedNames {
+		return name, nil
+	}
+	encrypted, err := base64.RawURLEncoding.DecodeString(name)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidData, err)
+	}
+	decrypted, err := c.DecryptRaw(encrypted)
+	if err != nil {
+		return "", err
+	}
+	plain := string(decrypted)
+	if !filepath.IsLocal(plain) || strings.ContainsAny(plain, `/\`) {
+		return "", fmt.Errorf("%w: decrypted name %q is not a file name", ErrInvalidData, plain)
+	}
+	return plain, nil
+}
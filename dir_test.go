@@ -0,0 +1,114 @@
+package cryptio
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptDir(t *testing.T) {
+	src := t.TempDir()
+	large := make([]byte, 3*streamChunkSize+17)
+	if _, err := rand.Read(large); err != nil {
+		t.Fatalf("rand.Read failed: %v", err)
+	}
+	files := map[string][]byte{
+		"notes.txt":             []byte("top-level note"),
+		"empty":                 nil,
+		"photos/2024/large.bin": large,
+		"photos/readme.md":      []byte("# Photos"),
+	}
+	for name, content := range files {
+		path := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		if err := os.WriteFile(path, content, 0o600); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+	if err := os.Chmod(filepath.Join(src, "notes.txt"), 0o640); err != nil {
+		t.Fatalf("Chmod failed: %v", err)
+	}
+
+	for _, encryptNames := range []bool{false, true} {
+		opts := []Option{}
+		if encryptNames {
+			opts = append(opts, WithEncryptedFilenames())
+		}
+		client, err := NewWithLevelProfile("DirSecret", SecurityUltraFast, ProfileBalanced, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		encrypted, restored := filepath.Join(t.TempDir(), "enc"), filepath.Join(t.TempDir(), "dec")
+		if err := client.EncryptDir(src, encrypted); err != nil {
+			t.Fatalf("EncryptDir (names encrypted: %v) failed: %v", encryptNames, err)
+		}
+		_, err = os.Stat(filepath.Join(encrypted, "notes.txt"))
+		if encryptNames == (err == nil) {
+			t.Errorf("notes.txt present in the encrypted tree = %v, want %v", err == nil, !encryptNames)
+		}
+		if err := client.DecryptDir(encrypted, restored); err != nil {
+			t.Fatalf("DecryptDir (names encrypted: %v) failed: %v", encryptNames, err)
+		}
+
+		count := 0
+		err = filepath.WalkDir(restored, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, _ := filepath.Rel(restored, path)
+			want, ok := files[filepath.ToSlash(rel)]
+			if !ok {
+				t.Errorf("Unexpected file %s in the restored tree", rel)
+				return nil
+			}
+			count++
+			got, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s: restored content differs", rel)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Walking the restored tree failed: %v", err)
+		}
+		if count != len(files) {
+			t.Errorf("Restored %d files, want %d", count, len(files))
+		}
+		info, err := os.Stat(filepath.Join(restored, "notes.txt"))
+		if err != nil {
+			t.Fatalf("Stat failed: %v", err)
+		}
+		if info.Mode().Perm() != 0o640 {
+			t.Errorf("notes.txt permissions = %v, want 0640", info.Mode().Perm())
+		}
+	}
+}
+
+func TestEncryptDirRejects(t *testing.T) {
+	client, err := NewWithLevelProfile("DirSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	src := t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, "target"), []byte("data"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := client.EncryptDir(src, filepath.Join(src, "backup")); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Expected ErrInvalidParams for a destination inside the source, got %v", err)
+	}
+	if err := os.Symlink("target", filepath.Join(src, "link")); err != nil {
+		t.Skipf("Symlinks unavailable: %v", err)
+	}
+	if err := client.EncryptDir(src, t.TempDir()); !errors.Is(err, ErrUnsupportedFile) {
+		t.Errorf("Expected ErrUnsupportedFile for a symlink, got %v", err)
+	}
+}
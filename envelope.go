@@ -0,0 +1,112 @@
+package cryptio
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Envelope format:
+//
+//	magic "CRYE" (4) | version (1) | wrapped key length (uint16 BE) | wrapped key
+//	| nonce (12) | AES-256-GCM ciphertext
+//
+// Everything before the nonce is authenticated as additional data.
+
+var envelopeMagic = []byte("CRYE")
+
+const (
+	envelopeVersion = 1
+	envelopeDEKSize = 32
+)
+
+// KeyWrapper protects data encryption keys with a key held elsewhere, typically
+// a KMS or HSM (AWS KMS, Vault transit, ...), which never reveals it.
+type KeyWrapper interface {
+	// Wrap encrypts dek and returns an opaque value for Unwrap.
+	Wrap(dek []byte) ([]byte, error)
+	// Unwrap returns the key wrapped by Wrap.
+	Unwrap(wrapped []byte) ([]byte, error)
+}
+
+// EncryptEnvelopeWith encrypts plaintext under a fresh random data key (DEK) and
+// stores the DEK wrapped by wrapper alongside the ciphertext, so no passphrase
+// or Argon2 run is involved: access to the data is governed by the wrapping key.
+func EncryptEnvelopeWith(plaintext []byte, wrapper KeyWrapper) ([]byte, error) {
+	dek := make([]byte, envelopeDEKSize)
+	if err := readRandom(rand.Reader, dek); err != nil {
+		return nil, err
+	}
+	defer clear(dek)
+	wrapped, err := wrapper.Wrap(dek)
+	if err != nil {
+		return nil, fmt.Errorf("wrap data key: %w", err)
+	}
+	if len(wrapped) > math.MaxUint16 {
+		return nil, fmt.Errorf("%w: wrapped key of %d bytes is too long", ErrInvalidParams, len(wrapped))
+	}
+	gcm, err := envelopeAEAD(dek)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(envelopeMagic)+3+len(wrapped)+gcmNonceSize+len(plaintext)+gcm.Overhead())
+	out = append(out, envelopeMagic...)
+	out = append(out, envelopeVersion)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(wrapped))) //nolint:gosec // checked above
+	out = append(out, wrapped...)
+	ad := out[:len(out):len(out)]
+	nonce := make([]byte, gcmNonceSize)
+	if err := readRandom(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plaintext, ad), nil
+}
+
+// DecryptEnvelopeWith decrypts data produced by EncryptEnvelopeWith, unwrapping
+// its data key with wrapper.
+func DecryptEnvelopeWith(data []byte, wrapper KeyWrapper) ([]byte, error) {
+	prefix := len(envelopeMagic) + 3
+	if len(data) < prefix || string(data[:len(envelopeMagic)]) != string(envelopeMagic) {
+		return nil, fmt.Errorf("%w: not an envelope", ErrInvalidData)
+	}
+	if data[len(envelopeMagic)] != envelopeVersion {
+		return nil, fmt.Errorf("%w: unsupported envelope version %d", ErrInvalidData, data[len(envelopeMagic)])
+	}
+	wrappedLen := int(binary.BigEndian.Uint16(data[len(envelopeMagic)+1:]))
+	if len(data) < prefix+wrappedLen+gcmNonceSize+gcmTagSize {
+		return nil, ErrInvalidData
+	}
+	ad := data[:prefix+wrappedLen]
+	dek, err := wrapper.Unwrap(data[prefix : prefix+wrappedLen])
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+	defer clear(dek)
+	gcm, err := envelopeAEAD(dek)
+	if err != nil {
+		return nil, err
+	}
+	nonce := data[len(ad) : len(ad)+gcmNonceSize]
+	plaintext, err := gcm.Open(nil, nonce, data[len(ad)+gcmNonceSize:], ad)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecryptFailed, err)
+	}
+	return plaintext, nil
+}
+
+// envelopeAEAD returns the AES-256-GCM instance for a data key.
+func envelopeAEAD(dek []byte) (cipher.AEAD, error) {
+	if len(dek) != envelopeDEKSize {
+		return nil, fmt.Errorf("%w: data key is %d bytes, want %d", ErrKeyDerivation, len(dek), envelopeDEKSize)
+	}
+	block, err := aes.NewCipher(dek)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyDerivation, err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,73 @@
+package cryptio
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+// memoryWrapper is a KeyWrapper standing in for a KMS, keeping its key in memory.
+type memoryWrapper struct {
+	aead cipher.AEAD
+}
+
+func newMemoryWrapper(t *testing.T) *memoryWrapper {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("rand.Read failed: %v", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatalf("aes.NewCipher failed: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("cipher.NewGCM failed: %v", err)
+	}
+	return &memoryWrapper{aead: aead}
+}
+
+func (w *memoryWrapper) Wrap(dek []byte) ([]byte, error) {
+	nonce := make([]byte, w.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return w.aead.Seal(nonce, nonce, dek, nil), nil
+}
+
+func (w *memoryWrapper) Unwrap(wrapped []byte) ([]byte, error) {
+	if len(wrapped) < w.aead.NonceSize() {
+		return nil, errors.New("wrapped key too short")
+	}
+	n := w.aead.NonceSize()
+	return w.aead.Open(nil, wrapped[:n], wrapped[n:], nil)
+}
+
+func TestEnvelopeWithKeyWrapper(t *testing.T) {
+	kms := newMemoryWrapper(t)
+	plaintext := []byte("customer record protected by the KMS")
+	envelope, err := EncryptEnvelopeWith(plaintext, kms)
+	if err != nil {
+		t.Fatalf("EncryptEnvelopeWith failed: %v", err)
+	}
+	decrypted, err := DecryptEnvelopeWith(envelope, kms)
+	if err != nil {
+		t.Fatalf("DecryptEnvelopeWith failed: %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("Expected %q, got %q", plaintext, decrypted)
+	}
+
+	if _, err := DecryptEnvelopeWith(envelope, newMemoryWrapper(t)); err == nil {
+		t.Error("DecryptEnvelopeWith with another wrapping key should fail, but did not")
+	}
+	tampered := bytes.Clone(envelope)
+	tampered[len(tampered)-1] ^= 0x01
+	if _, err := DecryptEnvelopeWith(tampered, kms); !errors.Is(err, ErrDecryptFailed) {
+		t.Errorf("Expected ErrDecryptFailed for a tampered envelope, got %v", err)
+	}
+}
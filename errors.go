@@ -0,0 +1,50 @@
+package cryptio
+
+import "errors"
+
+var (
+	// ErrInvalidParams is returned when a set of security parameters is unsafe or unusable.
+	ErrInvalidParams = errors.New("invalid security parameters")
+	// ErrInvalidData is returned when encrypted data is malformed or too short to be valid.
+	ErrInvalidData = errors.New("invalid encrypted data")
+	// ErrEmptyPassphrase is returned by New when the passphrase is empty.
+	ErrEmptyPassphrase = errors.New("empty passphrase")
+	// ErrSizeLimitExceeded is returned when decrypted output would exceed the configured maximum.
+	ErrSizeLimitExceeded = errors.New("decrypted size limit exceeded")
+	// ErrInvalidStream is returned when an encrypted stream is malformed, truncated or reordered.
+	ErrInvalidStream = errors.New("invalid encrypted stream")
+	// ErrAADMismatch is returned by DecryptWithAAD when the presented additional data does not match the blob's.
+	ErrAADMismatch = errors.New("additional data does not match")
+	// ErrKeyDerivation is returned when the derived key cannot be used to build the cipher.
+	ErrKeyDerivation = errors.New("key derivation failed")
+	// ErrAuthFailed is returned by NewDecryptingReader when the stream cannot be fully authenticated.
+	ErrAuthFailed = errors.New("stream authentication failed")
+	// ErrArgon2VersionMismatch is returned when data was derived with an Argon2 version this library does not implement.
+	ErrArgon2VersionMismatch = errors.New("Argon2 version mismatch")
+	// ErrWeakRandomness is returned when the random source produces an all-zero salt or nonce.
+	ErrWeakRandomness = errors.New("random source returned all-zero bytes")
+	// ErrPolicyViolation is returned when data was encrypted with parameters below the WithRejectBelowPolicy floor.
+	ErrPolicyViolation = errors.New("encrypted data is below the minimum policy")
+	// ErrNonceExhausted is returned when a namespace key has sealed as many messages as random nonces safely allow.
+	ErrNonceExhausted = errors.New("nonce space exhausted")
+	// ErrTruncatedStream is returned when a stream does not hold the total plaintext length recorded in its header.
+	ErrTruncatedStream = errors.New("truncated encrypted stream")
+	// ErrNotDeterministic is returned by SameContent for blobs not encrypted with WithDeterministic.
+	ErrNotDeterministic = errors.New("encrypted data is not deterministic")
+	// ErrInsufficientMemory is returned when an Argon2 derivation would exceed the process memory limit.
+	ErrInsufficientMemory = errors.New("insufficient memory for key derivation")
+	// ErrDecryptFailed is returned when a blob fails authentication: wrong passphrase or tampered data.
+	ErrDecryptFailed = errors.New("decryption failed")
+	// ErrWrongPrefix is returned by Decrypt when the input lacks the WithOutputPrefix prefix.
+	ErrWrongPrefix = errors.New("encrypted text has the wrong prefix")
+	// ErrRandomnessUnavailable is returned when the random source keeps failing to provide salts or nonces.
+	ErrRandomnessUnavailable = errors.New("random source unavailable")
+	// ErrChecksumMismatch is returned when an authentic blob's WithPlaintextChecksum checksum does not match its plaintext.
+	ErrChecksumMismatch = errors.New("plaintext checksum mismatch")
+	// ErrUnsupportedFile is returned by EncryptDir and DecryptDir for symbolic links and other non-regular files.
+	ErrUnsupportedFile = errors.New("unsupported file type")
+	// ErrSignatureInvalid is returned by DecryptAndVerify when the signature does not verify with the given key.
+	ErrSignatureInvalid = errors.New("invalid signature")
+	// ErrPassphraseMismatch is returned by NewFromTerminalConfirmed when the two passphrases typed differ.
+	ErrPassphraseMismatch = errors.New("passphrases do not match")
+)
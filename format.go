@@ -0,0 +1,241 @@
+package cryptio
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Blob format, multi-byte integers big-endian:
+//
+//	offset  size  field
+//	0       4     magic "CRYP"
+//	4       1     format version (1)
+//	5       1     flags, see below
+//	6       4     Argon2 time cost (uint32)
+//	10      4     Argon2 memory in KiB (uint32)
+//	14      1     Argon2 threads
+//	15      1     key size
+//	16      1     salt size (S)
+//	17      1     nonce size (N)
+//	18      1     tag size
+//	19      1     cipher, see Cipher
+//	20      1     Argon2 version (0x13)
+//	21      S     salt
+//	21+S    N     nonce, absent with flagDerivedNonce
+//	...           AEAD ciphertext and tag
+//
+// With flagImplicitParams, the Argon2 costs (offsets 6 to 14) are not stored and
+// the following fields move 9 bytes closer to the start. Decryption takes the
+// costs from the client, see WithImplicitParams.
+//
+// The header is authenticated as additional data, so the recorded parameters
+// cannot be altered without decryption failing. Implicit costs are authenticated
+// too: the additional data is the full header, rebuilt with them. TestBlobGoldenVectors pins the
+// layout with known answers for other implementations.
+
+const (
+	formatVersion      = 1
+	headerSize         = 21
+	implicitHeaderSize = headerSize - 9 // without the Argon2 costs, see flagImplicitParams
+
+	flagDerivedNonce   = 1 << 0 // nonce derived from the salt, not stored
+	flagPadded         = 1 << 1 // plaintext ends with random padding, see pad
+	flagDeterministic  = 1 << 2 // nonce synthesized from the plaintext, see syntheticNonce
+	flagIntegrity      = 1 << 3 // data stored in the clear after an HMAC tag, see SealIntegrity
+	flagSubkey         = 1 << 4 // AEAD keyed per message from the stored nonce, see messageAEAD
+	flagCompressed     = 1 << 5 // plaintext gzipped before padding, see compress
+	flagChecksum       = 1 << 6 // plaintext ends with its CRC-32C, see addChecksum
+	flagImplicitParams = 1 << 7 // Argon2 costs not stored, taken from the client's configuration
+
+	// subkeyNonceSize is the random nonce stored with flagSubkey: long enough
+	// that per-message keys never repeat, unlike 12-byte GCM nonces.
+	subkeyNonceSize = 32
+)
+
+var formatMagic = []byte("CRYP")
+
+// Upper bounds on the cost a blob header may request, so that untrusted input
+// cannot make the decrypting side allocate or spin without limit.
+const (
+	maxHeaderArgonMem  = 4 * 1024 * 1024 // 4 GiB
+	maxHeaderArgonTime = 64
+)
+
+// header is the self-describing prefix of every blob.
+type header struct {
+	flags   byte
+	cipher  Cipher
+	params  Params
+	tagSize int
+}
+
+// BlobInfo describes how a blob was encrypted, as recorded in its header.
+type BlobInfo struct {
+	Version        int    // Format version, 0 for headerless legacy blobs
+	Params         Params // Key derivation and cipher sizes
+	Cipher         Cipher // AEAD used for the ciphertext
+	TagSize        int    // Authentication tag size in bytes
+	DerivedNonce   bool   // Nonce derived from the salt instead of stored
+	Deterministic  bool   // Nonce synthesized from the plaintext, see WithDeterministic
+	Subkeys        bool   // Key derived per message from the nonce, see WithNonceDerivedSubkeys
+	Checksum       bool   // Plaintext checksum stored, see WithPlaintextChecksum
+	ImplicitParams bool   // Argon2 costs taken from the client, not the header, see WithImplicitParams
+}
+
+// info returns the public description of the header.
+func (h header) info() BlobInfo {
+	return BlobInfo{
+		Version:        formatVersion,
+		Params:         h.params,
+		Cipher:         h.cipher,
+		TagSize:        h.tagSize,
+		DerivedNonce:   h.flags&flagDerivedNonce != 0,
+		Deterministic:  h.flags&flagDeterministic != 0,
+		Subkeys:        h.flags&flagSubkey != 0,
+		Checksum:       h.flags&flagChecksum != 0,
+		ImplicitParams: h.flags&flagImplicitParams != 0,
+	}
+}
+
+// writeHeader appends the encoded header to dst, as stored in the blob.
+func writeHeader(dst []byte, h header) []byte {
+	dst = append(dst, formatMagic...)
+	dst = append(dst, formatVersion, h.flags)
+	if h.flags&flagImplicitParams == 0 {
+		dst = appendCosts(dst, h.params)
+	}
+	return appendSizes(dst, h)
+}
+
+// writeFullHeader appends the header to dst with the Argon2 costs, even when the
+// blob does not store them.
+func writeFullHeader(dst []byte, h header) []byte {
+	dst = append(dst, formatMagic...)
+	dst = append(dst, formatVersion, h.flags)
+	return appendSizes(appendCosts(dst, h.params), h)
+}
+
+// headerAAD returns the additional data authenticating a header whose stored
+// encoding is stored: stored itself, or the full header for implicit costs.
+func headerAAD(stored []byte, h header) []byte {
+	if h.flags&flagImplicitParams == 0 {
+		return stored
+	}
+	return writeFullHeader(nil, h)
+}
+
+func appendCosts(dst []byte, p Params) []byte {
+	dst = binary.BigEndian.AppendUint32(dst, p.ArgonTime)
+	dst = binary.BigEndian.AppendUint32(dst, p.ArgonMem)
+	return append(dst, p.ArgonThreads)
+}
+
+func appendSizes(dst []byte, h header) []byte {
+	return append(dst,
+		byte(h.params.KeySize),
+		byte(h.params.SaltSize),
+		byte(h.params.NonceSize),
+		byte(h.tagSize),
+		byte(h.cipher),
+		argon2.Version,
+	)
+}
+
+// readHeader decodes the header at the start of data and returns it with the remaining bytes.
+// The Argon2 costs of a header with flagImplicitParams are left zero, see readBlobHeader.
+func readHeader(data []byte) (header, []byte, error) {
+	if len(data) < implicitHeaderSize || string(data[:len(formatMagic)]) != string(formatMagic) {
+		return header{}, nil, ErrInvalidData
+	}
+	if data[4] != formatVersion {
+		return header{}, nil, fmt.Errorf("%w: unsupported format version %d", ErrInvalidData, data[4])
+	}
+	h := header{flags: data[5]}
+	if len(data) < h.size() {
+		return header{}, nil, ErrInvalidData
+	}
+	sizes := data[6:]
+	if h.flags&flagImplicitParams == 0 {
+		h.params.ArgonTime = binary.BigEndian.Uint32(data[6:])
+		h.params.ArgonMem = binary.BigEndian.Uint32(data[10:])
+		h.params.ArgonThreads = data[14]
+		sizes = data[15:]
+	}
+	h.params.KeySize = uint32(sizes[0])
+	h.params.SaltSize = int(sizes[1])
+	h.params.NonceSize = int(sizes[2])
+	h.tagSize = int(sizes[3])
+	h.cipher = Cipher(sizes[4])
+	if err := h.params.validateSizes(); err != nil {
+		return header{}, nil, err
+	}
+	if h.flags&flagImplicitParams == 0 {
+		if err := h.params.validate(); err != nil {
+			return header{}, nil, err
+		}
+	}
+	if err := h.validateCipher(); err != nil {
+		return header{}, nil, err
+	}
+	if sizes[5] != argon2.Version {
+		return header{}, nil, fmt.Errorf("%w: data uses version %#x, this library implements %#x", ErrArgon2VersionMismatch, sizes[5], argon2.Version)
+	}
+	if h.params.ArgonMem > maxHeaderArgonMem || h.params.ArgonTime > maxHeaderArgonTime {
+		return header{}, nil, fmt.Errorf("%w: header requests an excessive Argon2 cost", ErrInvalidData)
+	}
+	return h, data[h.size():], nil
+}
+
+// readBlobHeader is readHeader completing implicit Argon2 costs with the
+// client's own.
+func (c *Client) readBlobHeader(data []byte) (header, []byte, error) {
+	h, rest, err := readHeader(data)
+	if err == nil && h.flags&flagImplicitParams != 0 {
+		p := c.currentParams()
+		h.params.ArgonTime, h.params.ArgonMem, h.params.ArgonThreads = p.ArgonTime, p.ArgonMem, p.ArgonThreads
+	}
+	return h, rest, err
+}
+
+// IsCryptioBlob reports whether data starts with a valid blob header and is long
+// enough for the blob it describes, without deriving a key or decrypting, e.g. to
+// avoid encrypting data twice. Random bytes only pass by chance with a negligible
+// probability. Streams, integrity-only data and headerless legacy blobs, which
+// cannot be told from random bytes, are not recognized.
+func IsCryptioBlob(data []byte) bool {
+	h, _, err := readHeader(data)
+	return err == nil && h.flags&flagIntegrity == 0 && len(data) >= h.minBlobSize()
+}
+
+// size returns the encoded size of the header.
+func (h header) size() int {
+	if h.flags&flagImplicitParams != 0 {
+		return implicitHeaderSize
+	}
+	return headerSize
+}
+
+// storedNonceSize returns how many nonce bytes follow the salt in a blob.
+func (h header) storedNonceSize() int {
+	if h.flags&flagDerivedNonce != 0 {
+		return 0
+	}
+	return h.params.NonceSize
+}
+
+// minBlobSize returns the size of a blob with this header and an empty plaintext.
+// Anything shorter cannot hold the GCM tag and is rejected before key derivation.
+func (h header) minBlobSize() int {
+	return h.size() + h.params.SaltSize + h.storedNonceSize() + h.tagSize
+}
+
+// deriveNonce derives a nonce of the given size from a message salt with HKDF-SHA256.
+// Salts are random and used for a single message, so derived nonces never repeat
+// under the same key (and keys themselves differ per salt).
+func deriveNonce(salt []byte, size int) ([]byte, error) {
+	return hkdf.Key(sha256.New, salt, nil, "cryptio derived nonce", size)
+}
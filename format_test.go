@@ -0,0 +1,647 @@
+package cryptio
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"runtime"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+)
+
+func TestHeaderRoundTrip(t *testing.T) {
+	params, err := mergeParams(SecurityMedium, ProfileCPUHeavy)
+	if err != nil {
+		t.Fatalf("mergeParams failed: %v", err)
+	}
+	h := header{flags: flagDerivedNonce, params: params, tagSize: 14}
+
+	data := writeHeader(nil, h)
+	if len(data) != headerSize {
+		t.Fatalf("Expected header of %d bytes, got %d", headerSize, len(data))
+	}
+	h2, rest, err := readHeader(append(data, 0xAA))
+	if err != nil {
+		t.Fatalf("readHeader failed: %v", err)
+	}
+	if h2 != h {
+		t.Errorf("Expected header %+v, got %+v", h, h2)
+	}
+	if !bytes.Equal(rest, []byte{0xAA}) {
+		t.Errorf("Expected remaining bytes [0xAA], got %v", rest)
+	}
+}
+
+func TestReadHeaderRejectsInvalid(t *testing.T) {
+	params, err := mergeParams(SecurityStandard, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("mergeParams failed: %v", err)
+	}
+	valid := writeHeader(nil, header{params: params, tagSize: gcmTagSize})
+
+	tests := []struct {
+		name   string
+		mutate func(d []byte) []byte
+	}{
+		{"truncated", func(d []byte) []byte { return d[:headerSize-1] }},
+		{"bad magic", func(d []byte) []byte { d[0] = 'X'; return d }},
+		{"unknown version", func(d []byte) []byte { d[4] = 99; return d }},
+		{"zero threads", func(d []byte) []byte { d[14] = 0; return d }},
+		{"short salt", func(d []byte) []byte { d[16] = 4; return d }},
+		{"excessive memory", func(d []byte) []byte { d[10] = 0xFF; return d }},
+		{"short tag", func(d []byte) []byte { d[18] = 8; return d }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := readHeader(tt.mutate(bytes.Clone(valid))); err == nil {
+				t.Error("readHeader should fail, but did not")
+			}
+		})
+	}
+}
+
+func TestDerivedNonce(t *testing.T) {
+	client, err := NewWithLevelProfile("DerivedNonce", SecurityUltraFast, ProfileBalanced, WithDerivedNonce())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	plaintext := []byte("small record")
+
+	data, err := client.EncryptRaw(plaintext)
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+	if want := headerSize + client.params.SaltSize + len(plaintext) + 16; len(data) != want {
+		t.Errorf("Expected %d bytes without a stored nonce, got %d", want, len(data))
+	}
+
+	// A client without the option reads the mode from the header.
+	other, err := NewWithLevelProfile("DerivedNonce", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	plain2, err := other.DecryptRaw(data)
+	if err != nil {
+		t.Fatalf("DecryptRaw failed: %v", err)
+	}
+	if !bytes.Equal(plaintext, plain2) {
+		t.Errorf("Expected decrypted bytes %q, got %q", plaintext, plain2)
+	}
+}
+
+func TestDerivedNonceUniqueness(t *testing.T) {
+	client, err := NewWithLevelProfile("DerivedNonce", SecurityUltraFast, ProfileBalanced, WithDerivedNonce())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for range 8 {
+		data, err := client.EncryptRaw([]byte("same plaintext"))
+		if err != nil {
+			t.Fatalf("EncryptRaw failed: %v", err)
+		}
+		salt := data[headerSize : headerSize+client.params.SaltSize]
+		nonce, err := deriveNonce(salt, client.params.NonceSize)
+		if err != nil {
+			t.Fatalf("deriveNonce failed: %v", err)
+		}
+		if seen[string(nonce)] {
+			t.Fatal("Derived nonce repeated across encryptions")
+		}
+		seen[string(nonce)] = true
+	}
+
+	// Without paying for Argon2, check many fresh salts never collide.
+	salt := make([]byte, minSaltSize)
+	for i := range 100000 {
+		salt[0], salt[1], salt[2] = byte(i), byte(i>>8), byte(i>>16)
+		nonce, err := deriveNonce(salt, gcmNonceSize)
+		if err != nil {
+			t.Fatalf("deriveNonce failed: %v", err)
+		}
+		if seen[string(nonce)] {
+			t.Fatalf("Derived nonce repeated for salt %d", i)
+		}
+		seen[string(nonce)] = true
+	}
+}
+
+func TestDecryptRawWithInfo(t *testing.T) {
+	encrypter, err := NewWithLevelProfile("InfoSecret", SecurityUltraFast, ProfileTradeoff, WithDerivedNonce())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	decrypter, err := NewWithLevelProfile("InfoSecret", SecurityStandard, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	data, err := encrypter.EncryptRaw([]byte("migrate me"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+	plaintext, info, err := decrypter.DecryptRawWithInfo(data)
+	if err != nil {
+		t.Fatalf("DecryptRawWithInfo failed: %v", err)
+	}
+	if string(plaintext) != "migrate me" {
+		t.Errorf("Expected plaintext %q, got %q", "migrate me", plaintext)
+	}
+	if info.Params != encrypter.params {
+		t.Errorf("Expected params %+v, got %+v", encrypter.params, info.Params)
+	}
+	if !info.DerivedNonce || info.Version != formatVersion {
+		t.Errorf("Unexpected blob info %+v", info)
+	}
+}
+
+func TestDecryptRawBoundaryLengths(t *testing.T) {
+	for _, derived := range []bool{false, true} {
+		var opts []Option
+		if derived {
+			opts = append(opts, WithDerivedNonce())
+		}
+		client, err := NewWithLevelProfile("Boundary", SecurityUltraFast, ProfileBalanced, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		data, err := client.EncryptRaw(nil)
+		if err != nil {
+			t.Fatalf("EncryptRaw failed: %v", err)
+		}
+		h, _, err := readHeader(data)
+		if err != nil {
+			t.Fatalf("readHeader failed: %v", err)
+		}
+		minLen := h.minBlobSize()
+		if len(data) != minLen {
+			t.Fatalf("Expected an empty plaintext to produce exactly %d bytes, got %d", minLen, len(data))
+		}
+
+		// One byte short of the tag: rejected upfront.
+		_, err = client.DecryptRaw(data[:minLen-1])
+		if !errors.Is(err, ErrInvalidData) {
+			t.Errorf("derived=%v: expected ErrInvalidData at %d bytes, got %v", derived, minLen-1, err)
+		}
+		// Exactly the minimum: valid.
+		if _, err := client.DecryptRaw(data[:minLen]); err != nil {
+			t.Errorf("derived=%v: expected success at %d bytes, got %v", derived, minLen, err)
+		}
+		// Minimum length with a corrupted tag: passes the length check, fails authentication.
+		corrupted := bytes.Clone(data)
+		corrupted[minLen-1] ^= 0x01
+		_, err = client.DecryptRaw(corrupted)
+		if err == nil || errors.Is(err, ErrInvalidData) {
+			t.Errorf("derived=%v: expected an authentication error, got %v", derived, err)
+		}
+	}
+}
+
+func TestTagSize(t *testing.T) {
+	for _, tagSize := range []int{12, 16} {
+		client, err := NewWithLevelProfile("TagSecret", SecurityUltraFast, ProfileBalanced, WithTagSize(tagSize))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		plaintext := []byte("interop payload")
+		data, err := client.EncryptRaw(plaintext)
+		if err != nil {
+			t.Fatalf("EncryptRaw failed: %v", err)
+		}
+		if want := headerSize + client.params.SaltSize + client.params.NonceSize + len(plaintext) + tagSize; len(data) != want {
+			t.Errorf("tag %d: expected %d bytes, got %d", tagSize, want, len(data))
+		}
+
+		// The default client follows the tag size recorded in the header.
+		other, err := NewWithLevelProfile("TagSecret", SecurityUltraFast, ProfileBalanced)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		plain2, info, err := other.DecryptRawWithInfo(data)
+		if err != nil {
+			t.Fatalf("tag %d: DecryptRawWithInfo failed: %v", tagSize, err)
+		}
+		if !bytes.Equal(plaintext, plain2) || info.TagSize != tagSize {
+			t.Errorf("tag %d: unexpected round-trip result %q, %+v", tagSize, plain2, info)
+		}
+
+		// Claiming another tag size in the header must fail.
+		tampered := bytes.Clone(data)
+		tampered[18] = byte(28 - tagSize)
+		if _, err := other.DecryptRaw(tampered); err == nil {
+			t.Errorf("tag %d: decryption with a mismatched tag size should fail, but did not", tagSize)
+		}
+	}
+
+	for _, tagSize := range []int{0, 11, 17} {
+		_, err := NewWithLevelProfile("TagSecret", SecurityUltraFast, ProfileBalanced, WithTagSize(tagSize))
+		if !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("tag %d: expected ErrInvalidParams, got %v", tagSize, err)
+		}
+	}
+}
+
+func TestNonceSize(t *testing.T) {
+	for _, nonceSize := range []int{8, 16} {
+		client, err := NewWithLevelProfile("NonceSecret", SecurityUltraFast, ProfileBalanced, WithNonceSize(nonceSize))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		plaintext := []byte("legacy interop")
+		data, err := client.EncryptRaw(plaintext)
+		if err != nil {
+			t.Fatalf("EncryptRaw failed: %v", err)
+		}
+		if want := headerSize + client.params.SaltSize + nonceSize + len(plaintext) + gcmTagSize; len(data) != want {
+			t.Errorf("nonce %d: expected %d bytes, got %d", nonceSize, want, len(data))
+		}
+
+		// A default client parses the nonce using the size stored in the header.
+		other, err := NewWithLevelProfile("NonceSecret", SecurityUltraFast, ProfileBalanced)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		plain2, info, err := other.DecryptRawWithInfo(data)
+		if err != nil {
+			t.Fatalf("nonce %d: DecryptRawWithInfo failed: %v", nonceSize, err)
+		}
+		if !bytes.Equal(plaintext, plain2) || info.Params.NonceSize != nonceSize {
+			t.Errorf("nonce %d: unexpected round-trip result %q, %+v", nonceSize, plain2, info)
+		}
+	}
+
+	for _, nonceSize := range []int{0, -1, 256} {
+		_, err := NewWithLevelProfile("NonceSecret", SecurityUltraFast, ProfileBalanced, WithNonceSize(nonceSize))
+		if !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("nonce %d: expected ErrInvalidParams, got %v", nonceSize, err)
+		}
+	}
+	_, err := NewWithLevelProfile("NonceSecret", SecurityUltraFast, ProfileBalanced, WithNonceSize(8), WithTagSize(12))
+	if !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Expected ErrInvalidParams combining nonce and tag sizes, got %v", err)
+	}
+}
+
+func TestUniformSaltSize(t *testing.T) {
+	// Deriving keys at every level is too slow for a unit test: check the resolved
+	// parameters of each, and round-trip at the cheapest.
+	for _, level := range AllSecurityLevels() {
+		client, err := NewWithLevelProfile("SaltSecret", level, ProfileBalanced, WithUniformSaltSize(32))
+		if err != nil {
+			t.Fatalf("%v: Failed to create client: %v", level, err)
+		}
+		if client.params.SaltSize != 32 {
+			t.Errorf("%v: expected a 32-byte salt, got %d", level, client.params.SaltSize)
+		}
+	}
+
+	client, err := NewWithLevelProfile("SaltSecret", SecurityUltraFast, ProfileBalanced, WithUniformSaltSize(32))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	data, err := client.EncryptRaw([]byte("fixed-size salts"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+	other, err := NewWithLevelProfile("SaltSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	plaintext, info, err := other.DecryptRawWithInfo(data)
+	if err != nil {
+		t.Fatalf("DecryptRawWithInfo failed: %v", err)
+	}
+	if string(plaintext) != "fixed-size salts" || info.Params.SaltSize != 32 {
+		t.Errorf("Unexpected round-trip result %q, %+v", plaintext, info)
+	}
+
+	// Streams record their salt size too.
+	var encrypted, decrypted bytes.Buffer
+	if err := client.EncryptStream(&encrypted, bytes.NewReader([]byte("fixed-size stream salts"))); err != nil {
+		t.Fatalf("EncryptStream failed: %v", err)
+	}
+	sc, err := other.readStreamHeader(bytes.NewReader(encrypted.Bytes()))
+	if err != nil {
+		t.Fatalf("readStreamHeader failed: %v", err)
+	}
+	if sc.params.SaltSize != 32 {
+		t.Errorf("Stream records a %d-byte salt, want 32", sc.params.SaltSize)
+	}
+	if err := other.DecryptStream(&decrypted, &encrypted); err != nil {
+		t.Fatalf("DecryptStream failed: %v", err)
+	}
+	if decrypted.String() != "fixed-size stream salts" {
+		t.Errorf("Unexpected stream round-trip result %q", decrypted.String())
+	}
+
+	for _, saltSize := range []int{0, 15, 256} {
+		_, err := NewWithLevelProfile("SaltSecret", SecurityUltraFast, ProfileBalanced, WithUniformSaltSize(saltSize))
+		if !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("salt %d: expected ErrInvalidParams, got %v", saltSize, err)
+		}
+	}
+}
+
+func TestAutoThreadsRecordedInHeader(t *testing.T) {
+	client, err := NewWithLevelProfile("ThreadSecret", SecurityUltraFast, ProfileBalanced, WithAutoThreads())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	want := uint8(min(runtime.GOMAXPROCS(0), maxAutoThreads))
+	if client.params.ArgonThreads != want {
+		t.Errorf("Expected %d Argon2 threads, got %d", want, client.params.ArgonThreads)
+	}
+	data, err := client.EncryptRaw([]byte("parallel"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+
+	other, err := NewWithLevelProfile("ThreadSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	plaintext, info, err := other.DecryptRawWithInfo(data)
+	if err != nil {
+		t.Fatalf("DecryptRawWithInfo failed: %v", err)
+	}
+	if string(plaintext) != "parallel" || info.Params.ArgonThreads != want {
+		t.Errorf("Unexpected round-trip result %q, %+v", plaintext, info)
+	}
+
+	tampered := append([]byte{}, data...)
+	tampered[14] = want + 1
+	if _, err := other.DecryptRaw(tampered); err == nil {
+		t.Error("Decryption with a different thread count should fail, but did not")
+	}
+}
+
+func TestArgon2VersionMismatch(t *testing.T) {
+	client, err := NewWithLevelProfile("VersionSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	data, err := client.EncryptRaw([]byte("derived"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+	if data[20] != argon2.Version {
+		t.Fatalf("Expected Argon2 version %#x in the header, got %#x", argon2.Version, data[20])
+	}
+
+	data[20] = 0x10 // Argon2 1.0
+	if _, err := client.DecryptRaw(data); !errors.Is(err, ErrArgon2VersionMismatch) {
+		t.Errorf("Expected ErrArgon2VersionMismatch, got %v", err)
+	}
+}
+
+func TestRejectBelowPolicy(t *testing.T) {
+	standard, err := NewWithLevelProfile("PolicySecret", SecurityStandard, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	data, err := standard.EncryptRaw([]byte("weak blob"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+
+	high, err := ResolveParams(SecurityHigh, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("ResolveParams failed: %v", err)
+	}
+	strict, err := NewWithLevelProfile("PolicySecret", SecurityStandard, ProfileBalanced, WithRejectBelowPolicy(high))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := strict.DecryptRaw(data); !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("Expected ErrPolicyViolation, got %v", err)
+	}
+	plaintext, info, err := strict.DecryptRawWithInfo(data)
+	if err != nil {
+		t.Fatalf("DecryptRawWithInfo should still read the blob for migration: %v", err)
+	}
+	if string(plaintext) != "weak blob" || info.Params.ArgonMem >= high.ArgonMem {
+		t.Errorf("Unexpected migration read %q, %+v", plaintext, info)
+	}
+
+	lenient, err := NewWithLevelProfile("PolicySecret", SecurityStandard, ProfileBalanced, WithRejectBelowPolicy(Params{ArgonMem: 64 * 1024}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := lenient.DecryptRaw(data); err != nil {
+		t.Errorf("A blob meeting the policy should decrypt, got %v", err)
+	}
+}
+
+func TestUpgradeIfWeak(t *testing.T) {
+	client, err := NewWithLevelProfile("UpgradeSecret", SecurityUltraFast, ProfileCPUHeavy)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	target, err := ResolveParams(SecurityUltraFast, ProfileRAMHeavy)
+	if err != nil {
+		t.Fatalf("ResolveParams failed: %v", err)
+	}
+	weak, err := client.EncryptRaw([]byte("stored in 2019"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+
+	upgraded, changed, err := client.UpgradeIfWeak(weak, target)
+	if err != nil || !changed {
+		t.Fatalf("UpgradeIfWeak = %v, %v; want an upgrade", changed, err)
+	}
+	plaintext, info, err := client.DecryptRawWithInfo(upgraded)
+	if err != nil {
+		t.Fatalf("DecryptRawWithInfo failed: %v", err)
+	}
+	if string(plaintext) != "stored in 2019" || info.Params.ArgonMem != target.ArgonMem || info.Params.ArgonTime != target.ArgonTime {
+		t.Errorf("Upgraded blob holds %q with %+v, want target costs %+v", plaintext, info.Params, target)
+	}
+
+	same, changed, err := client.UpgradeIfWeak(upgraded, target)
+	if err != nil || changed || !bytes.Equal(same, upgraded) {
+		t.Errorf("UpgradeIfWeak on a strong blob = %v, %v; want it unchanged", changed, err)
+	}
+
+	legacy := encryptLegacy(t, client, []byte("headerless"))
+	if upgraded, changed, err := client.UpgradeIfWeak(legacy, target); err != nil || !changed || !IsCryptioBlob(upgraded) {
+		t.Errorf("UpgradeIfWeak on a legacy blob = %v, %v; want an upgrade", changed, err)
+	}
+
+	if _, _, err := client.UpgradeIfWeak(weak, Params{}); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Expected ErrInvalidParams for an invalid target, got %v", err)
+	}
+}
+
+func TestIsCryptioBlob(t *testing.T) {
+	client, err := NewWithLevelProfile("DetectSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	blob, err := client.EncryptRaw([]byte("already encrypted"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+	if !IsCryptioBlob(blob) {
+		t.Error("IsCryptioBlob should accept a real blob")
+	}
+
+	random := make([]byte, 256)
+	if _, err := rand.Read(random); err != nil {
+		t.Fatalf("rand.Read failed: %v", err)
+	}
+	badVersion := bytes.Clone(blob)
+	badVersion[4] = formatVersion + 1
+	for name, data := range map[string][]byte{
+		"empty":           nil,
+		"random":          random,
+		"plaintext":       []byte("CRYPTIC notes: nothing to see here, just plain text"),
+		"truncated":       blob[:headerSize+client.params.SaltSize+gcmNonceSize],
+		"header only":     blob[:headerSize],
+		"unknown version": badVersion,
+		"legacy":          encryptLegacy(t, client, []byte("headerless")),
+	} {
+		if IsCryptioBlob(data) {
+			t.Errorf("IsCryptioBlob should reject %s data", name)
+		}
+	}
+}
+
+func TestImplicitParams(t *testing.T) {
+	client, err := NewWithLevelProfile("ImplicitSecret", SecurityUltraFast, ProfileBalanced, WithImplicitParams())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	explicit, err := NewWithLevelProfile("ImplicitSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	blob, err := client.EncryptRaw([]byte("agreed out of band"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+	if got, want := client.Overhead(), explicit.Overhead()-(headerSize-implicitHeaderSize); got != want {
+		t.Errorf("Overhead() = %d, want %d", got, want)
+	}
+	if len(blob) != client.CiphertextLen(len("agreed out of band")) {
+		t.Errorf("Blob is %d bytes, want %d", len(blob), client.CiphertextLen(len("agreed out of band")))
+	}
+	if blob[6] != byte(client.params.KeySize) || !IsCryptioBlob(blob) {
+		t.Errorf("Header should go straight from the flags to the key size, got % x", blob[:implicitHeaderSize])
+	}
+
+	// Any client configured with the same costs decrypts the blob.
+	for name, c := range map[string]*Client{"implicit": client, "explicit": explicit} {
+		plaintext, info, err := c.DecryptRawWithInfo(blob)
+		if err != nil {
+			t.Fatalf("%s client: DecryptRawWithInfo failed: %v", name, err)
+		}
+		if string(plaintext) != "agreed out of band" || !info.ImplicitParams || info.Params != client.params {
+			t.Errorf("%s client: got %q with %+v", name, plaintext, info)
+		}
+	}
+
+	for _, profile := range []Argon2Profile{ProfileCPUHeavy, ProfileRAMHeavy} {
+		other, err := NewWithLevelProfile("ImplicitSecret", SecurityUltraFast, profile, WithImplicitParams())
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		if _, err := other.DecryptRaw(blob); !errors.Is(err, ErrDecryptFailed) {
+			t.Errorf("Client with other costs: expected ErrDecryptFailed, got %v", err)
+		}
+	}
+
+	// The costs are authenticated: the additional data is the full header, not
+	// the stored one.
+	h, rest, err := client.readBlobHeader(blob)
+	if err != nil {
+		t.Fatalf("readBlobHeader failed: %v", err)
+	}
+	salt, nonce := rest[:h.params.SaltSize], rest[h.params.SaltSize:h.params.SaltSize+h.params.NonceSize]
+	aead, nonce, err := client.messageAEAD(salt, nonce, h)
+	if err != nil {
+		t.Fatalf("messageAEAD failed: %v", err)
+	}
+	sealed := rest[h.params.SaltSize+h.params.NonceSize:]
+	if _, err := aead.Open(nil, nonce, sealed, blob[:implicitHeaderSize]); err == nil {
+		t.Error("Stored header alone should not authenticate the blob")
+	}
+	if _, err := aead.Open(nil, nonce, sealed, writeFullHeader(nil, h)); err != nil {
+		t.Errorf("Full header should authenticate the blob: %v", err)
+	}
+}
+
+func TestHeaderGoldenVectors(t *testing.T) {
+	for _, tc := range []struct {
+		level   SecurityLevel
+		profile Argon2Profile
+		h       header
+		hex     string
+	}{
+		// magic | version | flags | time | memory | threads | key | salt | nonce | tag | cipher | Argon2 version
+		{SecurityStandard, ProfileBalanced, header{tagSize: 16}, "43525950" + "01" + "00" + "00000002" + "00010000" + "01" + "20" + "10" + "0c" + "10" + "00" + "13"},
+		{SecurityMedium, ProfileCPUHeavy, header{flags: flagDerivedNonce, tagSize: 14}, "43525950" + "01" + "01" + "00000005" + "00020000" + "02" + "20" + "18" + "0c" + "0e" + "00" + "13"},
+	} {
+		params, err := mergeParams(tc.level, tc.profile)
+		if err != nil {
+			t.Fatalf("mergeParams failed: %v", err)
+		}
+		tc.h.params = params
+		if got := hex.EncodeToString(writeHeader(nil, tc.h)); got != tc.hex {
+			t.Errorf("%v/%v: writeHeader = %s, want %s", tc.level, tc.profile, got, tc.hex)
+		}
+		raw, _ := hex.DecodeString(tc.hex)
+		if h, _, err := readHeader(raw); err != nil || h != tc.h {
+			t.Errorf("%v/%v: readHeader = %+v, %v; want %+v", tc.level, tc.profile, h, err, tc.h)
+		}
+	}
+}
+
+func TestBlobGoldenVectors(t *testing.T) {
+	const passphrase, plaintext = "golden passphrase", "cryptio golden vector"
+	salt := make([]byte, 16) // 00 01 .. 0f
+	for i := range salt {
+		salt[i] = byte(i)
+	}
+	for _, tc := range []struct {
+		cipher    Cipher
+		nonceSize int
+		blob      string
+	}{
+		{CipherAESGCM, 12, "4352595001000000000200004c000120100c100013" + "000102030405060708090a0b0c0d0e0f" +
+			"a0a1a2a3a4a5a6a7a8a9aaab" + "d70f8acb5435dfdc6b5ccd165ed0acf0c5596a70c9a9d71ad6262cb758ca01ed055c2cd25d"},
+		{CipherXChaCha20Poly1305, 24, "4352595001000000000200004c0001201018100213" + "000102030405060708090a0b0c0d0e0f" +
+			"a0a1a2a3a4a5a6a7a8a9aaabacadaeafb0b1b2b3b4b5b6b7" + "94ec61739d1f2332dc71b572daa6d8257b4c10c1c26192392ad31539a80fd918186ad9c0a1"},
+	} {
+		blob, err := hex.DecodeString(tc.blob)
+		if err != nil {
+			t.Fatalf("%v: bad vector: %v", tc.cipher, err)
+		}
+		nonce := blob[headerSize+len(salt) : headerSize+len(salt)+tc.nonceSize] // a0 a1 ..
+
+		// The vector follows from the documented layout alone.
+		key := argon2.IDKey([]byte(passphrase), salt, 2, 19456, 1, 32)
+		aead, err := aeadFromKey(key, header{cipher: tc.cipher, params: Params{NonceSize: len(nonce)}, tagSize: gcmTagSize})
+		if err != nil {
+			t.Fatalf("%v: aeadFromKey failed: %v", tc.cipher, err)
+		}
+		if want := aead.Seal(bytes.Clone(blob[:headerSize+len(salt)+len(nonce)]), nonce, []byte(plaintext), blob[:headerSize]); !bytes.Equal(want, blob) {
+			t.Errorf("%v: vector does not match the specification", tc.cipher)
+		}
+
+		client, err := NewWithLevelProfile(passphrase, SecurityUltraFast, ProfileBalanced, WithCipher(tc.cipher),
+			WithSaltReader(bytes.NewReader(salt)), WithRand(bytes.NewReader(nonce)))
+		if err != nil {
+			t.Fatalf("%v: Failed to create client: %v", tc.cipher, err)
+		}
+		if got, err := client.EncryptRaw([]byte(plaintext)); err != nil || !bytes.Equal(got, blob) {
+			t.Errorf("%v: EncryptRaw = %x, %v; want the vector", tc.cipher, got, err)
+		}
+		if got, err := client.DecryptRaw(blob); err != nil || string(got) != plaintext {
+			t.Errorf("%v: DecryptRaw = %q, %v; want %q", tc.cipher, got, err, plaintext)
+		}
+	}
+}
@@ -2,6 +2,8 @@ module github.com/azrod/cryptio
 
 go 1.24.0
 
-require golang.org/x/crypto v0.42.0
-
-require golang.org/x/sys v0.36.0 // indirect
+require (
+	golang.org/x/crypto v0.42.0
+	golang.org/x/sys v0.36.0
+	golang.org/x/term v0.35.0
+)
@@ -0,0 +1,39 @@
+package cryptio
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// EncryptWithHeader encrypts plaintext and stores header unencrypted alongside it.
+// The header is authenticated as additional data, so it can be read by anyone but
+// not modified without DecryptWithHeader failing. The result is
+// header length (uint32 BE) + header + the EncryptRaw blob.
+func (c *Client) EncryptWithHeader(plaintext, header []byte) ([]byte, error) {
+	if uint64(len(header)) > math.MaxUint32 {
+		return nil, errors.New("header too large")
+	}
+	dst := make([]byte, 0, 4+len(header)+c.CiphertextLen(len(plaintext)))
+	dst = binary.BigEndian.AppendUint32(dst, uint32(len(header))) //nolint:gosec // checked above
+	dst = append(dst, header...)
+	return c.seal(dst, plaintext, dst)
+}
+
+// DecryptWithHeader decrypts data produced by EncryptWithHeader and returns the
+// plaintext together with the verified header.
+func (c *Client) DecryptWithHeader(data []byte) (plaintext, header []byte, err error) {
+	if len(data) < 4 {
+		return nil, nil, ErrInvalidData
+	}
+	headerLen := uint64(binary.BigEndian.Uint32(data))
+	if headerLen > uint64(len(data)-4) {
+		return nil, nil, ErrInvalidData
+	}
+	prefix := data[:4+headerLen]
+	plaintext, err = c.open(data[len(prefix):], prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	return plaintext, prefix[4:], nil
+}
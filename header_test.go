@@ -0,0 +1,54 @@
+package cryptio
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncryptDecryptWithHeader(t *testing.T) {
+	client, err := NewWithLevelProfile("HeaderSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	plaintext := []byte(`{"user":"alice"}`)
+	header := []byte("content-type: application/json")
+	data, err := client.EncryptWithHeader(plaintext, header)
+	if err != nil {
+		t.Fatalf("EncryptWithHeader failed: %v", err)
+	}
+	if !bytes.Contains(data, header) {
+		t.Error("Expected the header to be stored in plaintext")
+	}
+
+	plain2, header2, err := client.DecryptWithHeader(data)
+	if err != nil {
+		t.Fatalf("DecryptWithHeader failed: %v", err)
+	}
+	if !bytes.Equal(plaintext, plain2) {
+		t.Errorf("Expected plaintext %q, got %q", plaintext, plain2)
+	}
+	if !bytes.Equal(header, header2) {
+		t.Errorf("Expected header %q, got %q", header, header2)
+	}
+}
+
+func TestDecryptWithHeaderRejectsTamperedHeader(t *testing.T) {
+	client, err := NewWithLevelProfile("HeaderSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	data, err := client.EncryptWithHeader([]byte("payload"), []byte("content-type: text/plain"))
+	if err != nil {
+		t.Fatalf("EncryptWithHeader failed: %v", err)
+	}
+	tampered := bytes.Replace(data, []byte("text/plain"), []byte("text/html!"), 1)
+
+	if _, _, err := client.DecryptWithHeader(tampered); err == nil {
+		t.Error("Decryption should fail with a tampered header, but did not")
+	}
+	if _, _, err := client.DecryptWithHeader(data[:2]); err == nil {
+		t.Error("Decryption should fail on truncated data, but did not")
+	}
+}
@@ -0,0 +1,77 @@
+package cryptio
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+)
+
+// Integrity-only format:
+//
+//	header (with flagIntegrity) | salt | HMAC-SHA256 tag (32) | data
+//
+// The tag covers the header, the salt and the data, which is stored in the clear.
+
+// integrityKey derives the HMAC key of integrity-only data, kept apart from the
+// AEAD key derived for the same salt.
+func (c *Client) integrityKey(salt []byte, p Params) ([]byte, error) {
+	key, err := c.deriveKey(salt, p)
+	if err != nil {
+		return nil, err
+	}
+	return keyedHash(key, []byte("cryptio integrity")), nil
+}
+
+// SealIntegrity returns data prefixed with a tag authenticating it under the
+// passphrase, for content needing tamper-evidence but not confidentiality, such as
+// public logs. The data itself is not encrypted. Check it with VerifyIntegrity.
+func (c *Client) SealIntegrity(data []byte) ([]byte, error) {
+	h := c.newHeader()
+	h.flags = flagIntegrity
+	out := writeHeader(make([]byte, 0, headerSize+h.params.SaltSize+sha256.Size+len(data)), h)
+	salt := out[len(out) : len(out)+h.params.SaltSize]
+	if err := c.newSalt(salt); err != nil {
+		return nil, err
+	}
+	out = out[:len(out)+len(salt)]
+	key, err := c.integrityKey(salt, h.params)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(out)
+	mac.Write(data)
+	out = mac.Sum(out)
+	return append(out, data...), nil
+}
+
+// VerifyIntegrity checks data produced by SealIntegrity and returns the
+// authenticated content, or ErrInvalidData if it was modified.
+func (c *Client) VerifyIntegrity(data []byte) ([]byte, error) {
+	h, rest, err := readHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if h.flags != flagIntegrity {
+		return nil, fmt.Errorf("%w: not integrity-only data", ErrInvalidData)
+	}
+	if len(rest) < h.params.SaltSize+sha256.Size {
+		return nil, ErrInvalidData
+	}
+	signed := headerSize + h.params.SaltSize
+	salt, tag, content := rest[:h.params.SaltSize], data[signed:signed+sha256.Size], data[signed+sha256.Size:]
+	key, err := c.integrityKey(salt, h.params)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data[:signed])
+	mac.Write(content)
+	if !hmac.Equal(mac.Sum(nil), tag) {
+		c.logDebug("integrity check failed")
+		return nil, fmt.Errorf("%w: integrity check failed", ErrInvalidData)
+	}
+	return content, nil
+}
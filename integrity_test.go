@@ -0,0 +1,47 @@
+package cryptio
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSealIntegrity(t *testing.T) {
+	client, err := NewWithLevelProfile("IntegritySecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	content := []byte("2026-10-16 deploy v1.4.2 approved by ops")
+	sealed, err := client.SealIntegrity(content)
+	if err != nil {
+		t.Fatalf("SealIntegrity failed: %v", err)
+	}
+	if !bytes.HasSuffix(sealed, content) {
+		t.Error("Integrity-only data should be stored in the clear")
+	}
+	verified, err := client.VerifyIntegrity(sealed)
+	if err != nil {
+		t.Fatalf("VerifyIntegrity failed: %v", err)
+	}
+	if !bytes.Equal(verified, content) {
+		t.Errorf("Expected %q, got %q", content, verified)
+	}
+
+	for _, i := range []int{headerSize, len(sealed) - len(content) - 1, len(sealed) - 1} {
+		tampered := bytes.Clone(sealed)
+		tampered[i] ^= 0x01
+		if _, err := client.VerifyIntegrity(tampered); err == nil {
+			t.Errorf("VerifyIntegrity should fail with byte %d modified, but did not", i)
+		}
+	}
+
+	other, err := NewWithLevelProfile("OtherSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := other.VerifyIntegrity(sealed); err == nil {
+		t.Error("VerifyIntegrity with another passphrase should fail, but did not")
+	}
+	if _, err := client.DecryptRaw(sealed); err == nil {
+		t.Error("DecryptRaw should reject integrity-only data, but did not")
+	}
+}
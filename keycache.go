@@ -0,0 +1,74 @@
+package cryptio
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+)
+
+// keyCache is a size-bounded LRU of derived keys, see WithKeyCache.
+type keyCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List // of *keyCacheEntry, most recently used first
+	entries    map[[sha256.Size]byte]*list.Element
+}
+
+type keyCacheEntry struct {
+	id  [sha256.Size]byte
+	key []byte
+}
+
+func newKeyCache(maxEntries int) *keyCache {
+	return &keyCache{maxEntries: maxEntries, order: list.New(), entries: make(map[[sha256.Size]byte]*list.Element)}
+}
+
+// keyCacheID hashes what a derivation depends on besides the passphrase: the
+// salt and the Argon2 costs and output size.
+func keyCacheID(salt []byte, p Params) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write(salt)
+	var buf [13]byte
+	binary.BigEndian.PutUint32(buf[0:], p.ArgonTime)
+	binary.BigEndian.PutUint32(buf[4:], p.ArgonMem)
+	binary.BigEndian.PutUint32(buf[8:], p.KeySize)
+	buf[12] = p.ArgonThreads
+	h.Write(buf[:])
+	var id [sha256.Size]byte
+	h.Sum(id[:0])
+	return id
+}
+
+// get returns a copy of the key cached for salt and p, so that callers may
+// clear it without affecting the cache.
+func (kc *keyCache) get(salt []byte, p Params) ([]byte, bool) {
+	id := keyCacheID(salt, p)
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	elem, ok := kc.entries[id]
+	if !ok {
+		return nil, false
+	}
+	kc.order.MoveToFront(elem)
+	return bytes.Clone(elem.Value.(*keyCacheEntry).key), true
+}
+
+// put caches a copy of key, zeroing the least recently used key once the cache
+// is full.
+func (kc *keyCache) put(salt []byte, p Params, key []byte) {
+	id := keyCacheID(salt, p)
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	if elem, ok := kc.entries[id]; ok {
+		kc.order.MoveToFront(elem)
+		return
+	}
+	kc.entries[id] = kc.order.PushFront(&keyCacheEntry{id: id, key: bytes.Clone(key)})
+	for kc.order.Len() > kc.maxEntries {
+		oldest := kc.order.Remove(kc.order.Back()).(*keyCacheEntry)
+		clear(oldest.key)
+		delete(kc.entries, oldest.id)
+	}
+}
@@ -0,0 +1,69 @@
+package cryptio
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestKeyCache(t *testing.T) {
+	client, err := NewWithLevelProfile("CacheSecret", SecurityUltraFast, ProfileBalanced, WithKeyCache(2))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	blobs := make([][]byte, 3)
+	for i := range blobs {
+		if blobs[i], err = client.EncryptRaw([]byte{byte(i)}); err != nil {
+			t.Fatalf("EncryptRaw failed: %v", err)
+		}
+	}
+	derivations := client.derivations.Load()
+
+	for range 2 {
+		if _, err := client.DecryptRaw(blobs[2]); err != nil {
+			t.Fatalf("DecryptRaw failed: %v", err)
+		}
+	}
+	if n := client.derivations.Load() - derivations; n != 0 {
+		t.Errorf("Expected cached keys for recent blobs, got %d derivations", n)
+	}
+	// The first blob's key was evicted by the two later ones.
+	if _, err := client.DecryptRaw(blobs[0]); err != nil {
+		t.Fatalf("DecryptRaw failed: %v", err)
+	}
+	if n := client.derivations.Load() - derivations; n != 1 {
+		t.Errorf("Expected an evicted key to be derived again, got %d derivations", n)
+	}
+	if _, err := client.DecryptRaw(blobs[0]); err != nil {
+		t.Fatalf("DecryptRaw failed: %v", err)
+	}
+	if n := client.derivations.Load() - derivations; n != 1 {
+		t.Errorf("Expected the second decryption to skip Argon2, got %d derivations", n)
+	}
+
+	if _, err := NewWithLevelProfile("CacheSecret", SecurityUltraFast, ProfileBalanced, WithKeyCache(0)); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Expected ErrInvalidParams for an empty cache, got %v", err)
+	}
+}
+
+func TestKeyCacheZeroesEvictedKeys(t *testing.T) {
+	kc := newKeyCache(1)
+	p := Params{ArgonTime: 1, ArgonMem: 8, ArgonThreads: 1, KeySize: 32}
+	kc.put([]byte("first salt value"), p, bytes.Repeat([]byte{1}, 32))
+	evicted := kc.order.Front().Value.(*keyCacheEntry).key
+	kc.put([]byte("other salt value"), p, bytes.Repeat([]byte{2}, 32))
+	if !bytes.Equal(evicted, make([]byte, 32)) {
+		t.Errorf("Evicted key was not zeroed: %x", evicted)
+	}
+	if _, ok := kc.get([]byte("first salt value"), p); ok {
+		t.Error("Evicted key is still cached")
+	}
+	key, ok := kc.get([]byte("other salt value"), p)
+	if !ok || !bytes.Equal(key, bytes.Repeat([]byte{2}, 32)) {
+		t.Errorf("get = %x, %v; want the cached key", key, ok)
+	}
+	clear(key)
+	if again, _ := kc.get([]byte("other salt value"), p); !bytes.Equal(again, bytes.Repeat([]byte{2}, 32)) {
+		t.Error("Clearing a returned key should not affect the cache")
+	}
+}
@@ -0,0 +1,83 @@
+package cryptio
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// Blobs written before the versioned header are a bare salt | nonce | ciphertext,
+// sealed with AES-GCM (12-byte nonce, 16-byte tag) and no additional data. Their
+// parameters are not recorded, so they can only be opened with the client's own,
+// or those listed with WithDecryptFallbackParams.
+
+// isLegacyBlob reports whether data lacks the versioned blob header.
+func isLegacyBlob(data []byte) bool {
+	return !bytes.HasPrefix(data, formatMagic)
+}
+
+// legacyHeader describes a headerless blob sealed with p.
+func legacyHeader(p Params) header {
+	h := header{params: p, tagSize: gcmTagSize}
+	h.params.NonceSize = gcmNonceSize
+	return h
+}
+
+// openLegacy decrypts a headerless blob with the client's configured parameters,
+// then with each fallback in turn, until one authenticates, and returns the
+// header describing it. With enforcePolicy, parameters below the
+// WithRejectBelowPolicy floor are skipped, and ErrPolicyViolation is returned if
+// no other candidate authenticates.
+func (c *Client) openLegacy(data []byte, enforcePolicy bool) ([]byte, header, error) {
+	candidates := append([]Params{c.currentParams()}, c.fallbackParams...)
+	err := ErrInvalidData
+	var policyErr error
+	for _, p := range candidates {
+		if enforcePolicy {
+			if perr := c.checkPolicy(p); perr != nil {
+				if policyErr == nil {
+					policyErr = perr
+				}
+				continue
+			}
+		}
+		plaintext, openErr := c.openLegacyWith(data, p)
+		if openErr == nil {
+			if c.metrics != nil {
+				c.metrics.Decrypted()
+			}
+			return plaintext, legacyHeader(p), nil
+		}
+		switch {
+		case errors.Is(openErr, ErrDecryptFailed):
+			err = openErr
+		case openErr != ErrInvalidData:
+			return nil, header{}, openErr
+		}
+	}
+	if policyErr != nil {
+		return nil, header{}, policyErr
+	}
+	if errors.Is(err, ErrDecryptFailed) && c.metrics != nil {
+		c.metrics.AuthFailed()
+	}
+	return nil, header{}, err
+}
+
+// openLegacyWith decrypts a headerless blob assuming it was sealed with p.
+func (c *Client) openLegacyWith(data []byte, p Params) ([]byte, error) {
+	h := legacyHeader(p)
+	saltSize := h.params.SaltSize
+	if len(data) < saltSize+gcmNonceSize+gcmTagSize {
+		return nil, ErrInvalidData
+	}
+	gcm, err := c.newAEAD(data[:saltSize], h)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, data[saltSize:saltSize+gcmNonceSize], data[saltSize+gcmNonceSize:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecryptFailed, err)
+	}
+	return plaintext, nil
+}
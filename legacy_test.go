@@ -0,0 +1,196 @@
+package cryptio
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+// encryptLegacy reproduces EncryptRaw as it was before the versioned header.
+func encryptLegacy(t *testing.T, c *Client, plaintext []byte) []byte {
+	t.Helper()
+	salt := make([]byte, c.params.SaltSize)
+	nonce := make([]byte, gcmNonceSize)
+	if _, err := rand.Read(salt); err != nil {
+		t.Fatalf("rand.Read failed: %v", err)
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		t.Fatalf("rand.Read failed: %v", err)
+	}
+	key, err := c.deriveKey(salt, c.params)
+	if err != nil {
+		t.Fatalf("deriveKey failed: %v", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatalf("aes.NewCipher failed: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("cipher.NewGCM failed: %v", err)
+	}
+	return gcm.Seal(append(salt, nonce...), nonce, plaintext, nil)
+}
+
+func TestDecryptRawLegacyAndVersioned(t *testing.T) {
+	client, err := NewWithLevelProfile("LegacySecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	legacy := encryptLegacy(t, client, []byte("stored long ago"))
+	versioned, err := client.EncryptRaw([]byte("stored today"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+
+	for blob, want := range map[string]string{string(legacy): "stored long ago", string(versioned): "stored today"} {
+		plaintext, err := client.DecryptRaw([]byte(blob))
+		if err != nil {
+			t.Fatalf("DecryptRaw(%q) failed: %v", want, err)
+		}
+		if string(plaintext) != want {
+			t.Errorf("Expected %q, got %q", want, plaintext)
+		}
+	}
+
+	legacy[len(legacy)-1] ^= 1
+	if _, err := client.DecryptRaw(legacy); err == nil {
+		t.Error("Decrypting a tampered legacy blob should fail, but did not")
+	}
+	if _, err := client.DecryptRaw(legacy[:client.params.SaltSize]); err != ErrInvalidData {
+		t.Errorf("Expected ErrInvalidData for a short legacy blob, got %v", err)
+	}
+}
+
+func TestDecryptFallbackParams(t *testing.T) {
+	old, err := NewWithLevelProfile("LegacySecret", SecurityUltraFast, ProfileCPUHeavy)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	legacy := encryptLegacy(t, old, []byte("written with the old level"))
+
+	current, err := NewWithLevelProfile("LegacySecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := current.DecryptRaw(legacy); !errors.Is(err, ErrDecryptFailed) {
+		t.Fatalf("Expected ErrDecryptFailed without fallbacks, got %v", err)
+	}
+
+	oldParams, err := ResolveParams(SecurityUltraFast, ProfileCPUHeavy)
+	if err != nil {
+		t.Fatalf("ResolveParams failed: %v", err)
+	}
+	unrelated, err := ResolveParams(SecurityUltraFast, ProfileTradeoff)
+	if err != nil {
+		t.Fatalf("ResolveParams failed: %v", err)
+	}
+	migrating, err := NewWithLevelProfile("LegacySecret", SecurityUltraFast, ProfileBalanced,
+		WithDecryptFallbackParams([]Params{unrelated, oldParams}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	plaintext, err := migrating.DecryptRaw(legacy)
+	if err != nil {
+		t.Fatalf("DecryptRaw with fallback params failed: %v", err)
+	}
+	if string(plaintext) != "written with the old level" {
+		t.Errorf("Unexpected plaintext %q", plaintext)
+	}
+
+	if _, err := NewWithLevelProfile("LegacySecret", SecurityUltraFast, ProfileBalanced,
+		WithDecryptFallbackParams([]Params{{KeySize: 7}})); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Expected ErrInvalidParams for invalid fallback params, got %v", err)
+	}
+}
+
+func TestDecryptFallbackParamsPolicy(t *testing.T) {
+	old, err := NewWithLevelProfile("LegacySecret", SecurityUltraFast, ProfileCPUHeavy)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	weak := encryptLegacy(t, old, []byte("written with the old level"))
+	oldParams, err := ResolveParams(SecurityUltraFast, ProfileCPUHeavy)
+	if err != nil {
+		t.Fatalf("ResolveParams failed: %v", err)
+	}
+
+	// The floor sits between the old parameters and the client's own.
+	floor := Params{ArgonMem: oldParams.ArgonMem + 1}
+	strict, err := NewWithLevelProfile("LegacySecret", SecurityUltraFast, ProfileBalanced,
+		WithDecryptFallbackParams([]Params{oldParams}), WithRejectBelowPolicy(floor))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if strict.params.ArgonMem < floor.ArgonMem {
+		t.Fatalf("The client's own memory %d KiB should meet the floor", strict.params.ArgonMem)
+	}
+	if _, err := strict.DecryptRaw(weak); !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("Expected ErrPolicyViolation for fallback params below the floor, got %v", err)
+	}
+	if _, err := strict.DecryptRawInto(nil, weak); !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("DecryptRawInto: expected ErrPolicyViolation, got %v", err)
+	}
+	if plaintext, err := strict.DecryptRaw(encryptLegacy(t, strict, []byte("current"))); err != nil || string(plaintext) != "current" {
+		t.Errorf("DecryptRaw of a legacy blob meeting the floor = %q, %v", plaintext, err)
+	}
+
+	// Upgrading remains the way out for weak legacy blobs.
+	target, err := ResolveParams(SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("ResolveParams failed: %v", err)
+	}
+	upgraded, changed, err := strict.UpgradeIfWeak(weak, target)
+	if err != nil || !changed {
+		t.Fatalf("UpgradeIfWeak = %v, %v; want an upgrade", changed, err)
+	}
+	if plaintext, err := strict.DecryptRaw(upgraded); err != nil || string(plaintext) != "written with the old level" {
+		t.Errorf("DecryptRaw of the upgraded blob = %q, %v", plaintext, err)
+	}
+}
+
+func TestMigrateCipherLegacy(t *testing.T) {
+	old, err := NewWithLevelProfile("LegacySecret", SecurityUltraFast, ProfileCPUHeavy)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	plaintext := []byte("written before the header")
+	legacy := encryptLegacy(t, old, plaintext)
+	oldParams, err := ResolveParams(SecurityUltraFast, ProfileCPUHeavy)
+	if err != nil {
+		t.Fatalf("ResolveParams failed: %v", err)
+	}
+	migrating, err := NewWithLevelProfile("LegacySecret", SecurityUltraFast, ProfileBalanced,
+		WithDecryptFallbackParams([]Params{oldParams}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	decrypted, info, err := migrating.DecryptRawWithInfo(legacy)
+	if err != nil {
+		t.Fatalf("DecryptRawWithInfo failed on a legacy blob: %v", err)
+	}
+	if string(decrypted) != string(plaintext) {
+		t.Errorf("Expected %q, got %q", plaintext, decrypted)
+	}
+	if info.Version != 0 || info.Cipher != CipherAESGCM || info.Params.ArgonMem != oldParams.ArgonMem {
+		t.Errorf("Unexpected legacy blob info: %+v", info)
+	}
+
+	migrated, err := migrating.MigrateCipher(legacy, CipherChaCha20Poly1305)
+	if err != nil {
+		t.Fatalf("MigrateCipher failed on a legacy blob: %v", err)
+	}
+	decrypted, info, err = migrating.DecryptRawWithInfo(migrated)
+	if err != nil {
+		t.Fatalf("DecryptRawWithInfo after migration failed: %v", err)
+	}
+	if string(decrypted) != string(plaintext) {
+		t.Errorf("Expected %q, got %q", plaintext, decrypted)
+	}
+	if info.Version != formatVersion || info.Cipher != CipherChaCha20Poly1305 || info.Params.ArgonMem != oldParams.ArgonMem {
+		t.Errorf("Unexpected migrated blob info: %+v", info)
+	}
+}
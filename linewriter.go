@@ -0,0 +1,116 @@
+package cryptio
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// LineEncryptingWriter encrypts a live, line-oriented feed (logs, chat, ...) as a
+// stream in which every line is sealed and sent as soon as it is complete, so the
+// reader can decrypt it without waiting for more data. The output is a regular
+// stream: DecryptStream releases each line as its frame arrives.
+type LineEncryptingWriter struct {
+	sc      *streamCipher
+	dst     io.Writer
+	buf     []byte
+	frame   []byte
+	counter uint32
+	err     error
+}
+
+// NewLineEncryptingWriter writes the stream header to dst and returns a writer
+// sealing one frame per line. Close must be called to mark the end of the stream.
+func (c *Client) NewLineEncryptingWriter(dst io.Writer) (*LineEncryptingWriter, error) {
+	p := c.currentParams()
+	header, err := c.newStreamHeader(0, p, 0)
+	if err != nil {
+		return nil, err
+	}
+	sc, err := c.newStreamCipher(header, p)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dst.Write(header); err != nil {
+		return nil, err
+	}
+	return &LineEncryptingWriter{sc: sc, dst: dst}, nil
+}
+
+// Write buffers p and seals every complete line, up to and including its newline,
+// as its own frame. Lines longer than a stream chunk are split across frames.
+func (w *LineEncryptingWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n := len(p)
+	for len(p) > 0 {
+		line, rest, found := bytes.Cut(p, []byte{'\n'})
+		take := min(len(line), w.sc.chunkSize-len(w.buf))
+		w.buf = append(w.buf, line[:take]...)
+		p = p[take:]
+		if take == len(line) && found {
+			w.buf = append(w.buf, '\n')
+			p = rest
+		}
+		if (take == len(line) && found) || len(w.buf) == w.sc.chunkSize {
+			if err := w.Flush(); err != nil {
+				return n - len(p), err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Flush seals the buffered bytes, even without a trailing newline, and sends
+// them downstream, flushing dst too when it has a Flush() error method.
+func (w *LineEncryptingWriter) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.buf) == 0 {
+		return nil
+	}
+	return w.emit(false)
+}
+
+// Close seals the remaining buffered bytes as the final frame. It does not close dst.
+func (w *LineEncryptingWriter) Close() error {
+	if w.err != nil {
+		if errors.Is(w.err, errWriterClosed) {
+			return nil
+		}
+		return w.err
+	}
+	if err := w.emit(true); err != nil {
+		return err
+	}
+	w.err = errWriterClosed
+	return nil
+}
+
+// errWriterClosed is returned by writes after Close.
+var errWriterClosed = errors.New("write to closed LineEncryptingWriter")
+
+// emit seals the buffer as the next frame and writes it to dst.
+func (w *LineEncryptingWriter) emit(final bool) error {
+	if !final && w.counter == streamMaxChunks-1 {
+		w.err = fmt.Errorf("%w: too many chunks", ErrInvalidStream)
+		return w.err
+	}
+	w.frame = w.sc.sealFrame(w.frame[:0], w.buf, w.counter, final)
+	w.counter++
+	w.buf = w.buf[:0]
+	if _, err := w.dst.Write(w.frame); err != nil {
+		w.err = err
+		return err
+	}
+	if f, ok := w.dst.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			w.err = err
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package cryptio
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+// chanWriter forwards every write to a channel, as a live consumer would see it.
+type chanWriter chan string
+
+func (c chanWriter) Write(p []byte) (int, error) {
+	c <- string(p)
+	return len(p), nil
+}
+
+func TestLineEncryptingWriter(t *testing.T) {
+	client, err := NewWithLevelProfile("LineSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	pr, pw := io.Pipe()
+	lines := make(chanWriter, 10)
+	done := make(chan error, 1)
+	go func() { done <- client.DecryptStream(lines, pr) }()
+
+	w, err := client.NewLineEncryptingWriter(pw)
+	if err != nil {
+		t.Fatalf("NewLineEncryptingWriter failed: %v", err)
+	}
+	expect := func(want string) {
+		t.Helper()
+		select {
+		case got := <-lines:
+			if got != want {
+				t.Errorf("Expected %q, got %q", want, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for %q", want)
+		}
+	}
+
+	if _, err := w.Write([]byte("first line\nsecond ")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	expect("first line\n")
+	if _, err := w.Write([]byte("line\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	expect("second line\n")
+	if _, err := w.Write([]byte("prompt> ")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	expect("prompt> ")
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	pw.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("DecryptStream failed: %v", err)
+	}
+	if _, err := w.Write([]byte("late\n")); err == nil {
+		t.Error("Write after Close should fail, but did not")
+	}
+}
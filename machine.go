@@ -0,0 +1,31 @@
+package cryptio
+
+import (
+	"bytes"
+	"errors"
+	"os"
+)
+
+// MachineIDSource provides a stable identifier of the machine the process runs on.
+type MachineIDSource interface {
+	MachineID() ([]byte, error)
+}
+
+// FileMachineID reads the machine identifier from a file, such as /etc/machine-id.
+type FileMachineID string
+
+// DefaultMachineID is the source used by WithMachineBinding when none is given.
+const DefaultMachineID FileMachineID = "/etc/machine-id"
+
+// MachineID implements MachineIDSource.
+func (f FileMachineID) MachineID() ([]byte, error) {
+	id, err := os.ReadFile(string(f))
+	if err != nil {
+		return nil, err
+	}
+	id = bytes.TrimSpace(id)
+	if len(id) == 0 {
+		return nil, errors.New("empty machine identifier")
+	}
+	return id, nil
+}
@@ -0,0 +1,58 @@
+package cryptio
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type fakeMachineID string
+
+func (f fakeMachineID) MachineID() ([]byte, error) {
+	return []byte(f), nil
+}
+
+func TestMachineBinding(t *testing.T) {
+	clientA, err := NewWithLevelProfile("BoundSecret", SecurityUltraFast, ProfileBalanced, WithMachineBinding(fakeMachineID("machine-a")))
+	if err != nil {
+		t.Fatalf("Failed to create client A: %v", err)
+	}
+	clientA2, err := NewWithLevelProfile("BoundSecret", SecurityUltraFast, ProfileBalanced, WithMachineBinding(fakeMachineID("machine-a")))
+	if err != nil {
+		t.Fatalf("Failed to create client A2: %v", err)
+	}
+	clientB, err := NewWithLevelProfile("BoundSecret", SecurityUltraFast, ProfileBalanced, WithMachineBinding(fakeMachineID("machine-b")))
+	if err != nil {
+		t.Fatalf("Failed to create client B: %v", err)
+	}
+
+	ciphertext, err := clientA.Encrypt("machine bound")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if _, err := clientA2.Decrypt(ciphertext); err != nil {
+		t.Errorf("Decryption on the same machine failed: %v", err)
+	}
+	if _, err := clientB.Decrypt(ciphertext); err == nil {
+		t.Error("Decryption on another machine should fail, but did not")
+	}
+}
+
+func TestFileMachineID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "machine-id")
+	if err := os.WriteFile(path, []byte("0123456789abcdef\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	id, err := FileMachineID(path).MachineID()
+	if err != nil {
+		t.Fatalf("MachineID failed: %v", err)
+	}
+	if string(id) != "0123456789abcdef" {
+		t.Errorf("Expected trimmed machine ID, got %q", id)
+	}
+
+	_, err = NewWithLevelProfile("BoundSecret", SecurityUltraFast, ProfileBalanced, WithMachineBinding(FileMachineID(filepath.Join(t.TempDir(), "missing"))))
+	if err == nil {
+		t.Error("New should fail when the machine ID cannot be read, but did not")
+	}
+}
@@ -0,0 +1,40 @@
+package cryptio
+
+import (
+	"fmt"
+	"math"
+	"runtime/debug"
+	"runtime/metrics"
+)
+
+// memoryInUseMetric is the total memory mapped by the Go runtime.
+const memoryInUseMetric = "/memory/classes/total:bytes"
+
+// checkArgon2Memory probes whether the Argon2 memory of p fits under the process
+// memory limit (GOMEMLIMIT or debug.SetMemoryLimit) given what is already in use.
+// Argon2 allocates its whole matrix upfront, so on a memory-starved host it would
+// panic or get the process OOM-killed instead of failing; this turns that into
+// ErrInsufficientMemory. Without a memory limit there is nothing to probe against.
+func checkArgon2Memory(p Params) error {
+	limit := debug.SetMemoryLimit(-1)
+	if limit == math.MaxInt64 {
+		return nil
+	}
+	sample := []metrics.Sample{{Name: memoryInUseMetric}}
+	metrics.Read(sample)
+	var inUse uint64
+	if sample[0].Value.Kind() == metrics.KindUint64 {
+		inUse = sample[0].Value.Uint64()
+	}
+
+	need := uint64(p.ArgonMem) * 1024
+	if available := uint64(limit); inUse+need > available {
+		var free uint64
+		if available > inUse {
+			free = available - inUse
+		}
+		return fmt.Errorf("%w: Argon2 needs %d MiB but %d MiB remain under the %d MiB memory limit; use a lower security level",
+			ErrInsufficientMemory, need>>20, free>>20, available>>20)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package cryptio
+
+import (
+	"errors"
+	"runtime/debug"
+	"testing"
+)
+
+func TestInsufficientMemory(t *testing.T) {
+	client, err := NewWithLevelProfile("MemorySecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// A memory limit below what is already in use leaves no room for Argon2.
+	previous := debug.SetMemoryLimit(1 << 20)
+	_, err = client.Encrypt("starved")
+	debug.SetMemoryLimit(previous)
+	if !errors.Is(err, ErrInsufficientMemory) {
+		t.Fatalf("Expected ErrInsufficientMemory under a tight memory limit, got %v", err)
+	}
+
+	if _, err := client.Encrypt("not starved"); err != nil {
+		t.Errorf("Encrypt failed once the limit was lifted: %v", err)
+	}
+}
@@ -0,0 +1,19 @@
+package cryptio
+
+import "time"
+
+// Metrics receives operational counters from a client, e.g. to alert on a spike
+// of authentication failures. Methods are called on the encryption path, from any
+// goroutine: implementations must be safe for concurrent use and cheap, such as
+// atomic counters or expvar variables.
+type Metrics interface {
+	// Encrypted is called for every blob sealed.
+	Encrypted()
+	// Decrypted is called for every blob successfully decrypted.
+	Decrypted()
+	// AuthFailed is called for every blob failing authentication: wrong
+	// passphrase, wrong additional data or tampered data.
+	AuthFailed()
+	// KeyDerived is called after every Argon2 run with its duration.
+	KeyDerived(d time.Duration)
+}
@@ -0,0 +1,52 @@
+package cryptio
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingMetrics is a Metrics sink made of atomic counters.
+type countingMetrics struct {
+	encrypted, decrypted, authFailed, derivations atomic.Int64
+	derivationTime                                atomic.Int64
+}
+
+func (m *countingMetrics) Encrypted()  { m.encrypted.Add(1) }
+func (m *countingMetrics) Decrypted()  { m.decrypted.Add(1) }
+func (m *countingMetrics) AuthFailed() { m.authFailed.Add(1) }
+func (m *countingMetrics) KeyDerived(d time.Duration) {
+	m.derivations.Add(1)
+	m.derivationTime.Add(int64(d))
+}
+
+func TestWithMetrics(t *testing.T) {
+	m := &countingMetrics{}
+	client, err := NewWithLevelProfile("MetricsSecret", SecurityUltraFast, ProfileBalanced, WithMetrics(m))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	encrypted, err := client.EncryptRaw([]byte("counted"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+	if m.encrypted.Load() != 1 || m.derivations.Load() != 1 || m.derivationTime.Load() <= 0 {
+		t.Errorf("After encrypt: encrypted=%d derivations=%d time=%d", m.encrypted.Load(), m.derivations.Load(), m.derivationTime.Load())
+	}
+
+	if _, err := client.DecryptRaw(encrypted); err != nil {
+		t.Fatalf("DecryptRaw failed: %v", err)
+	}
+	if m.decrypted.Load() != 1 || m.authFailed.Load() != 0 {
+		t.Errorf("After decrypt: decrypted=%d authFailed=%d", m.decrypted.Load(), m.authFailed.Load())
+	}
+
+	encrypted[len(encrypted)-1] ^= 0x01
+	if _, err := client.DecryptRaw(encrypted); err == nil {
+		t.Fatal("DecryptRaw should fail on tampered data, but did not")
+	}
+	if m.decrypted.Load() != 1 || m.authFailed.Load() != 1 {
+		t.Errorf("After failed decrypt: decrypted=%d authFailed=%d", m.decrypted.Load(), m.authFailed.Load())
+	}
+}
@@ -0,0 +1,34 @@
+package cryptio
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// mimeLineLength is the maximum base64 line length allowed by MIME (RFC 2045).
+const mimeLineLength = 76
+
+// EncryptMIME is Encrypt with the base64 output wrapped at 76 columns with CRLF
+// line breaks, for email bodies and formats rejecting long lines.
+func (c *Client) EncryptMIME(plaintext string) (string, error) {
+	raw, err := c.EncryptRaw([]byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.StdEncoding.EncodeToString(raw)
+	var b strings.Builder
+	b.Grow(len(encoded) + 2*(len(encoded)/mimeLineLength+1))
+	for len(encoded) > mimeLineLength {
+		b.WriteString(encoded[:mimeLineLength])
+		b.WriteString("\r\n")
+		encoded = encoded[mimeLineLength:]
+	}
+	b.WriteString(encoded)
+	return b.String(), nil
+}
+
+// DecryptMIME decrypts the output of EncryptMIME. Like Decrypt, it ignores line
+// breaks; MIME output never carries the WithOutputPrefix prefix.
+func (c *Client) DecryptMIME(encryptedText string) (string, error) {
+	return c.decryptBase64(encryptedText, nil)
+}
@@ -0,0 +1,35 @@
+package cryptio
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEncryptDecryptMIME(t *testing.T) {
+	client, err := NewWithLevelProfile("MIMESecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	plaintext := strings.Repeat("long enough to need several lines ", 8)
+	wrapped, err := client.EncryptMIME(plaintext)
+	if err != nil {
+		t.Fatalf("EncryptMIME failed: %v", err)
+	}
+	lines := strings.Split(wrapped, "\r\n")
+	if len(lines) < 3 {
+		t.Fatalf("Expected several lines, got %d", len(lines))
+	}
+	for i, line := range lines {
+		if len(line) > mimeLineLength || (i < len(lines)-1 && len(line) != mimeLineLength) {
+			t.Errorf("Line %d has %d columns", i, len(line))
+		}
+	}
+
+	decrypted, err := client.DecryptMIME(wrapped)
+	if err != nil {
+		t.Fatalf("DecryptMIME failed: %v", err)
+	}
+	if decrypted != plaintext {
+		t.Errorf("Expected %q, got %q", plaintext, decrypted)
+	}
+}
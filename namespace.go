@@ -0,0 +1,115 @@
+package cryptio
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// namespaceKey caches the key derived from a namespace salt.
+type namespaceKey struct {
+	params Params
+	key    []byte
+}
+
+// NewNamespace creates a client whose blobs and streams all use namespaceSalt
+// instead of a random salt per message. Argon2 then runs once, the first time the
+// key is needed, and every later encryption or decryption in the namespace only
+// draws a fresh random nonce.
+//
+// The tradeoff: one key protects every message of the namespace, so an attacker
+// can test passphrase guesses against all of them at once, and the number of
+// messages is bounded by the nonce size: blobs fail with ErrNonceExhausted after
+// 2^32 messages with 12-byte nonces in the lifetime of the client, so prefer
+// CipherXChaCha20Poly1305 for large namespaces. Streams only have a 7-byte random
+// nonce prefix and should stay rare in a namespace. WithDerivedNonce is rejected,
+// since it would repeat the nonce. The salt must be 16 to 255 bytes long.
+func NewNamespace(passphrase string, namespaceSalt []byte, level SecurityLevel, profile Argon2Profile, opts ...Option) (*Client, error) {
+	if len(namespaceSalt) < minSaltSize || len(namespaceSalt) > maxHeaderField {
+		return nil, fmt.Errorf("%w: namespace salt must be %d-%d bytes, got %d", ErrInvalidParams, minSaltSize, maxHeaderField, len(namespaceSalt))
+	}
+	salt := append([]byte{}, namespaceSalt...)
+	opts = append(opts, func(c *Client) error {
+		c.fixedSalt = salt
+		return nil
+	})
+	c, err := NewWithLevelProfile(passphrase, level, profile, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if c.derivedNonce {
+		return nil, errors.New("WithDerivedNonce cannot be used with a namespace salt")
+	}
+	return c, nil
+}
+
+// Warmup derives and caches the namespace key now, so that the first encryption
+// or decryption does not pay for Argon2. Clients never derive keys when created.
+// Without a namespace salt, every message has its own key and there is nothing to
+// cache: Warmup only checks that a derivation would fit in memory.
+func (c *Client) Warmup() error {
+	p := c.currentParams()
+	if c.fixedSalt == nil {
+		return checkArgon2Memory(p)
+	}
+	_, err := c.deriveKey(c.fixedSalt, p)
+	return err
+}
+
+// namespaceNonceLimit returns how many random nonces of nonceSize bytes can be drawn
+// under one key while keeping the collision probability below 2^-32: about
+// 2^((8*nonceSize-32)/2) by the birthday bound, i.e. 2^32 for 12-byte nonces.
+func namespaceNonceLimit(nonceSize int) uint64 {
+	shift := 4*nonceSize - 16
+	if shift >= 64 {
+		return math.MaxUint64
+	}
+	return 1 << max(shift, 0)
+}
+
+// countNamespaceNonce accounts for a new random nonce under the namespace key and
+// fails with ErrNonceExhausted once the safe limit is reached.
+func (c *Client) countNamespaceNonce(nonceSize int) error {
+	if c.fixedSalt == nil {
+		return nil
+	}
+	if c.nsMessages.Add(1) > namespaceNonceLimit(nonceSize) {
+		return fmt.Errorf("%w: more than %d messages under one namespace key", ErrNonceExhausted, namespaceNonceLimit(nonceSize))
+	}
+	return nil
+}
+
+// newSalt fills salt for a new message: the namespace salt when there is one,
+// fresh random bytes otherwise.
+func (c *Client) newSalt(salt []byte) error {
+	if c.fixedSalt != nil {
+		copy(salt, c.fixedSalt)
+		return nil
+	}
+	return c.readFresh(c.saltReader, salt)
+}
+
+// cachedKey returns a copy of the key for salt and p if it was derived from the
+// namespace salt, so that callers may clear it without affecting the cache.
+func (c *Client) cachedKey(salt []byte, p Params) ([]byte, bool) {
+	if c.fixedSalt == nil || !bytes.Equal(salt, c.fixedSalt) {
+		return nil, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.nsKey == nil || c.nsKey.params != p {
+		return nil, false
+	}
+	return bytes.Clone(c.nsKey.key), true
+}
+
+// cacheKey remembers a copy of key when salt is the namespace salt.
+func (c *Client) cacheKey(salt []byte, p Params, key []byte) {
+	if c.fixedSalt == nil || !bytes.Equal(salt, c.fixedSalt) {
+		return
+	}
+	c.mu.Lock()
+	c.nsKey = &namespaceKey{params: p, key: bytes.Clone(key)}
+	c.mu.Unlock()
+}
@@ -0,0 +1,267 @@
+package cryptio
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNamespaceDerivesOnce(t *testing.T) {
+	salt := []byte("tenant-42 namespace salt")
+	client, err := NewNamespace("NamespaceSecret", salt, SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	blobs := make([][]byte, 1000)
+	for i := range blobs {
+		blobs[i], err = client.EncryptRaw([]byte(fmt.Sprintf("message %d", i)))
+		if err != nil {
+			t.Fatalf("EncryptRaw failed: %v", err)
+		}
+	}
+	for i, blob := range blobs {
+		plaintext, err := client.DecryptRaw(blob)
+		if err != nil {
+			t.Fatalf("DecryptRaw failed: %v", err)
+		}
+		if want := fmt.Sprintf("message %d", i); string(plaintext) != want {
+			t.Errorf("Expected %q, got %q", want, plaintext)
+		}
+	}
+	if n := client.derivations.Load(); n != 1 {
+		t.Errorf("Expected a single Argon2 derivation, got %d", n)
+	}
+	if !bytes.Equal(blobs[0][headerSize:headerSize+len(salt)], salt) {
+		t.Error("Blobs should carry the namespace salt")
+	}
+
+	// A regular client with the same passphrase reads namespace blobs.
+	other, err := NewWithLevelProfile("NamespaceSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if plaintext, err := other.DecryptRaw(blobs[7]); err != nil || string(plaintext) != "message 7" {
+		t.Errorf("DecryptRaw with a regular client failed: %q, %v", plaintext, err)
+	}
+}
+
+func TestWarmup(t *testing.T) {
+	// Construction never derives, even at the costliest level.
+	levels := AllSecurityLevels()
+	salt := []byte("tenant-42 namespace salt")
+	costly, err := NewNamespace("NamespaceSecret", salt, levels[len(levels)-1], ProfileRAMHeavy)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if n := costly.derivations.Load(); n != 0 {
+		t.Errorf("Expected no Argon2 derivation in NewNamespace, got %d", n)
+	}
+
+	client, err := NewNamespace("NamespaceSecret", salt, SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if n := client.derivations.Load(); n != 0 {
+		t.Fatalf("Expected no Argon2 derivation in NewNamespace, got %d", n)
+	}
+	if err := client.Warmup(); err != nil {
+		t.Fatalf("Warmup failed: %v", err)
+	}
+	if n := client.derivations.Load(); n != 1 {
+		t.Errorf("Expected Warmup to derive once, got %d", n)
+	}
+	blob, err := client.EncryptRaw([]byte("warm"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+	if _, err := client.DecryptRaw(blob); err != nil {
+		t.Fatalf("DecryptRaw failed: %v", err)
+	}
+	if n := client.derivations.Load(); n != 1 {
+		t.Errorf("Expected the warmed-up key to be reused, got %d derivations", n)
+	}
+
+	regular, err := NewWithLevelProfile("NamespaceSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := regular.Warmup(); err != nil || regular.derivations.Load() != 0 {
+		t.Errorf("Warmup without a namespace = %v with %d derivations, want nil and 0", err, regular.derivations.Load())
+	}
+}
+
+func TestNamespaceInvalid(t *testing.T) {
+	if _, err := NewNamespace("NamespaceSecret", []byte("short"), SecurityUltraFast, ProfileBalanced); err == nil {
+		t.Error("NewNamespace with a short salt should fail, but did not")
+	}
+	salt := []byte("tenant-42 namespace salt")
+	if _, err := NewNamespace("NamespaceSecret", salt, SecurityUltraFast, ProfileBalanced, WithDerivedNonce()); err == nil {
+		t.Error("NewNamespace with WithDerivedNonce should fail, but did not")
+	}
+}
+
+func TestNamespaceNonceLimit(t *testing.T) {
+	salt := []byte("tenant-42 namespace salt")
+	client, err := NewNamespace("NamespaceSecret", salt, SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	limit := namespaceNonceLimit(gcmNonceSize)
+	if limit != 1<<32 {
+		t.Fatalf("Expected a 2^32 limit for 12-byte nonces, got %d", limit)
+	}
+	client.nsMessages.Store(limit - 1)
+	if _, err := client.EncryptRaw([]byte("last one")); err != nil {
+		t.Fatalf("EncryptRaw at the limit failed: %v", err)
+	}
+	if _, err := client.EncryptRaw([]byte("one too many")); !errors.Is(err, ErrNonceExhausted) {
+		t.Errorf("Expected ErrNonceExhausted past the limit, got %v", err)
+	}
+
+	xchacha, err := NewNamespace("NamespaceSecret", salt, SecurityUltraFast, ProfileBalanced, WithCipher(CipherXChaCha20Poly1305))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	xchacha.nsMessages.Store(limit)
+	if _, err := xchacha.EncryptRaw([]byte("plenty of room")); err != nil {
+		t.Errorf("XChaCha20 namespaces should not be limited at 2^32 messages: %v", err)
+	}
+}
+
+func TestNonceDerivedSubkeys(t *testing.T) {
+	salt := []byte("high volume namespace salt")
+	client, err := NewNamespace("VolumeSecret", salt, SecurityUltraFast, ProfileBalanced, WithNonceDerivedSubkeys())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	encrypted, err := client.EncryptRaw([]byte("message"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+	_, info, err := client.DecryptRawWithInfo(encrypted)
+	if err != nil {
+		t.Fatalf("DecryptRawWithInfo failed: %v", err)
+	}
+	if !info.Subkeys || info.Params.NonceSize != subkeyNonceSize {
+		t.Errorf("Expected a subkey blob with a %d-byte nonce, got %+v", subkeyNonceSize, info)
+	}
+	other, err := NewWithLevelProfile("VolumeSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if plaintext, err := other.DecryptRaw(encrypted); err != nil || string(plaintext) != "message" {
+		t.Errorf("DecryptRaw failed: %q, %v", plaintext, err)
+	}
+
+	// Simulate far more messages than random 12-byte nonces allow under one key.
+	regular, err := NewNamespace("VolumeSecret", salt, SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	const sealed = 1 << 40
+	regular.nsMessages.Store(sealed)
+	if _, err := regular.EncryptRaw([]byte("message")); !errors.Is(err, ErrNonceExhausted) {
+		t.Errorf("Expected ErrNonceExhausted past 2^32 GCM nonces, got %v", err)
+	}
+	client.nsMessages.Store(sealed)
+	encrypted, err = client.EncryptRaw([]byte("message"))
+	if err != nil {
+		t.Fatalf("EncryptRaw with subkeys failed past 2^40 messages: %v", err)
+	}
+	if plaintext, err := client.DecryptRaw(encrypted); err != nil || string(plaintext) != "message" {
+		t.Errorf("DecryptRaw failed: %q, %v", plaintext, err)
+	}
+
+	if _, err := NewWithLevelProfile("VolumeSecret", SecurityUltraFast, ProfileBalanced, WithNonceDerivedSubkeys()); err == nil {
+		t.Error("WithNonceDerivedSubkeys without a namespace salt should fail, but did not")
+	}
+	if _, err := NewNamespace("VolumeSecret", salt, SecurityUltraFast, ProfileBalanced, WithNonceDerivedSubkeys(), WithCipher(CipherChaCha20Poly1305)); err == nil {
+		t.Error("WithNonceDerivedSubkeys with ChaCha20-Poly1305 should fail, but did not")
+	}
+}
+
+func TestNamespaceSaltContextDerivesOnce(t *testing.T) {
+	client, err := NewNamespace("ContextSecret", []byte("namespace with context"), SecurityUltraFast, ProfileBalanced, WithSaltContext([]byte("app")))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	for range 3 {
+		if _, err := client.EncryptRaw([]byte("data")); err != nil {
+			t.Fatalf("EncryptRaw failed: %v", err)
+		}
+	}
+	if n := client.derivations.Load(); n != 1 {
+		t.Errorf("Expected a single key derivation, got %d", n)
+	}
+}
+
+func TestNamespaceKeyCopies(t *testing.T) {
+	salt := []byte("namespace salt for key copies")
+	client, err := NewNamespace("CopySecret", salt, SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	other, err := NewWithLevelProfile("CopySecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	// Clear the key returned by the derivation that fills the cache, then one
+	// served from the cache: neither may zero the cached key.
+	for i := range 2 {
+		key, err := client.deriveKey(salt, client.params)
+		if err != nil {
+			t.Fatalf("deriveKey failed: %v", err)
+		}
+		clear(key)
+		encrypted, err := client.EncryptRaw([]byte("message"))
+		if err != nil {
+			t.Fatalf("EncryptRaw failed: %v", err)
+		}
+		if plaintext, err := other.DecryptRaw(encrypted); err != nil || string(plaintext) != "message" {
+			t.Errorf("Round %d: DecryptRaw after clearing a returned key = %q, %v", i, plaintext, err)
+		}
+	}
+	if n := client.derivations.Load(); n != 1 {
+		t.Errorf("Expected a single key derivation, got %d", n)
+	}
+}
+
+func TestNamespaceMigrateCipher(t *testing.T) {
+	writer, err := NewWithLevelProfile("NamespaceSecret", SecurityUltraFast, ProfileBalanced, WithDerivedNonce())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	data, err := writer.EncryptRaw([]byte("moved into the namespace"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+	// The namespace salt is longer than the one recorded in data.
+	salt := bytes.Repeat([]byte{0x5a}, writer.params.SaltSize+8)
+	client, err := NewNamespace("NamespaceSecret", salt, SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("NewNamespace failed: %v", err)
+	}
+
+	migrated, err := client.MigrateCipher(data, CipherXChaCha20Poly1305)
+	if err != nil {
+		t.Fatalf("MigrateCipher failed: %v", err)
+	}
+	plaintext, info, err := client.DecryptRawWithInfo(migrated)
+	if err != nil {
+		t.Fatalf("DecryptRawWithInfo after migration failed: %v", err)
+	}
+	if string(plaintext) != "moved into the namespace" {
+		t.Errorf("Unexpected plaintext %q", plaintext)
+	}
+	if info.Params.SaltSize != len(salt) || info.DerivedNonce {
+		t.Errorf("Migrated blob should use the namespace salt with a random nonce: %+v", info)
+	}
+	h, rest, err := readHeader(migrated)
+	if err != nil {
+		t.Fatalf("readHeader failed: %v", err)
+	}
+	if !bytes.Equal(rest[:h.params.SaltSize], salt) {
+		t.Error("Migrated blob should store the namespace salt")
+	}
+}
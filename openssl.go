@@ -0,0 +1,98 @@
+package cryptio
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+)
+
+// OpenSSL format, as written by
+// openssl enc -aes-256-cbc -pbkdf2 -salt -pass ...:
+//
+//	magic "Salted__" (8) | salt (8) | AES-256-CBC ciphertext, PKCS#7 padded
+//
+// Key and IV are the 48 bytes of PBKDF2-HMAC-SHA256(passphrase, salt, 10000).
+// openssl enc does not support AEAD modes, so there is no GCM variant to match.
+
+const (
+	openSSLIterations = 10000 // openssl enc -pbkdf2 default
+	openSSLSaltSize   = 8
+	openSSLKeySize    = 32
+)
+
+var openSSLMagic = []byte("Salted__")
+
+// openSSLCipher derives the key and IV for salt, as openssl enc -pbkdf2 does.
+func (c *Client) openSSLCipher(salt []byte) (cipher.Block, []byte, error) {
+	keyIV, err := pbkdf2.Key(sha256.New, string(c.passphrase), salt, openSSLIterations, openSSLKeySize+aes.BlockSize)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrKeyDerivation, err)
+	}
+	block, err := aes.NewCipher(keyIV[:openSSLKeySize])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrKeyDerivation, err)
+	}
+	return block, keyIV[openSSLKeySize:], nil
+}
+
+// EncryptOpenSSL encrypts plaintext in the format of
+// openssl enc -aes-256-cbc -pbkdf2, so the result decrypts with
+// openssl enc -d -aes-256-cbc -pbkdf2 and the same passphrase.
+// The format is for exchanging data with openssl only: it is not authenticated,
+// uses PBKDF2 instead of Argon2, and ignores the client's pepper, machine binding
+// and security level.
+func (c *Client) EncryptOpenSSL(plaintext []byte) ([]byte, error) {
+	salt := make([]byte, openSSLSaltSize)
+	if err := c.readFresh(c.saltReader, salt); err != nil {
+		return nil, err
+	}
+	block, iv, err := c.openSSLCipher(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	padLen := aes.BlockSize - len(plaintext)%aes.BlockSize
+	out := make([]byte, 0, len(openSSLMagic)+openSSLSaltSize+len(plaintext)+padLen)
+	out = append(append(out, openSSLMagic...), salt...)
+	start := len(out)
+	out = append(out, plaintext...)
+	out = append(out, bytes.Repeat([]byte{byte(padLen)}, padLen)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out[start:], out[start:])
+	return out, nil
+}
+
+// DecryptOpenSSL decrypts data produced by EncryptOpenSSL or by
+// openssl enc -aes-256-cbc -pbkdf2 (base64-decode it first if -a was used).
+// Nothing authenticates the data: a wrong passphrase is only detected by invalid
+// padding, and tampering may go unnoticed.
+func (c *Client) DecryptOpenSSL(data []byte) ([]byte, error) {
+	if len(data) < len(openSSLMagic)+openSSLSaltSize+aes.BlockSize || !bytes.HasPrefix(data, openSSLMagic) {
+		return nil, fmt.Errorf("%w: not openssl salted data", ErrInvalidData)
+	}
+	salt := data[len(openSSLMagic) : len(openSSLMagic)+openSSLSaltSize]
+	ciphertext := data[len(openSSLMagic)+openSSLSaltSize:]
+	if len(ciphertext)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("%w: ciphertext is not a whole number of blocks", ErrInvalidData)
+	}
+	block, iv, err := c.openSSLCipher(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+	padLen := int(plaintext[len(plaintext)-1])
+	valid := subtle.ConstantTimeLessOrEq(1, padLen) & subtle.ConstantTimeLessOrEq(padLen, aes.BlockSize)
+	for i := 1; i <= aes.BlockSize; i++ {
+		inPad := subtle.ConstantTimeLessOrEq(i, padLen)
+		valid &= subtle.ConstantTimeSelect(inPad, subtle.ConstantTimeByteEq(plaintext[len(plaintext)-i], byte(padLen)), 1)
+	}
+	if valid != 1 {
+		return nil, fmt.Errorf("%w: bad padding, wrong passphrase or corrupted data", ErrInvalidData)
+	}
+	return plaintext[:len(plaintext)-padLen], nil
+}
@@ -0,0 +1,59 @@
+package cryptio
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+// openSSLFixture was produced by
+// printf 'exported from openssl\n' | openssl enc -aes-256-cbc -pbkdf2 -salt -pass pass:OpenSSLSecret | base64
+const openSSLFixture = "U2FsdGVkX18ZA9DZPFcqoFcadIAXSri2izl7D6+Wj7WVaTtkS11r5u9U5sF/RJWg"
+
+func TestDecryptOpenSSLFixture(t *testing.T) {
+	client, err := NewWithLevelProfile("OpenSSLSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(openSSLFixture)
+	if err != nil {
+		t.Fatalf("Invalid fixture: %v", err)
+	}
+	plaintext, err := client.DecryptOpenSSL(data)
+	if err != nil {
+		t.Fatalf("DecryptOpenSSL failed: %v", err)
+	}
+	if string(plaintext) != "exported from openssl\n" {
+		t.Errorf("Expected the openssl plaintext, got %q", plaintext)
+	}
+
+	wrong, err := NewWithLevelProfile("WrongSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if plaintext, err := wrong.DecryptOpenSSL(data); err == nil && string(plaintext) == "exported from openssl\n" {
+		t.Error("DecryptOpenSSL with a wrong passphrase should not recover the plaintext")
+	}
+}
+
+func TestEncryptOpenSSLRoundTrip(t *testing.T) {
+	client, err := NewWithLevelProfile("OpenSSLSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	for _, plaintext := range []string{"", "sixteen bytes!!!", "some longer text for openssl"} {
+		encrypted, err := client.EncryptOpenSSL([]byte(plaintext))
+		if err != nil {
+			t.Fatalf("EncryptOpenSSL failed: %v", err)
+		}
+		if string(encrypted[:8]) != "Salted__" {
+			t.Errorf("Expected the openssl magic, got %q", encrypted[:8])
+		}
+		decrypted, err := client.DecryptOpenSSL(encrypted)
+		if err != nil {
+			t.Fatalf("DecryptOpenSSL failed: %v", err)
+		}
+		if string(decrypted) != plaintext {
+			t.Errorf("Expected %q, got %q", plaintext, decrypted)
+		}
+	}
+}
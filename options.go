@@ -0,0 +1,423 @@
+package cryptio
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// Option configures optional Client behavior.
+type Option func(*Client) error
+
+// WithDerivedNonce derives the nonce of each message from its salt with HKDF
+// instead of storing a random one, saving NonceSize bytes per blob.
+// The mode is recorded in the blob header, so any client can decrypt the result.
+func WithDerivedNonce() Option {
+	return func(c *Client) error {
+		c.derivedNonce = true
+		return nil
+	}
+}
+
+// WithAllowEmptyPassphrase lets New accept an empty passphrase.
+// The resulting key only depends on the public salt, so the data is merely
+// obfuscated: anyone can decrypt it. Use it only when that is the intent.
+func WithAllowEmptyPassphrase() Option {
+	return func(c *Client) error {
+		c.allowEmpty = true
+		return nil
+	}
+}
+
+// WithStreamIndex makes EncryptStream append a sealed index of chunk offsets,
+// letting SeekableReader locate any chunk without scanning the whole stream.
+func WithStreamIndex() Option {
+	return func(c *Client) error {
+		c.streamIndex = true
+		return nil
+	}
+}
+
+// WithMachineBinding mixes a machine identifier into key derivation, so data
+// only decrypts on the machine that encrypted it. A nil source uses DefaultMachineID.
+// The identifier is read once, when the client is created.
+func WithMachineBinding(source MachineIDSource) Option {
+	return func(c *Client) error {
+		if source == nil {
+			source = DefaultMachineID
+		}
+		c.machineSource = source
+		return nil
+	}
+}
+
+// WithPepper combines a secret pepper with the passphrase, as HMAC-SHA256(pepper, passphrase),
+// before key derivation. Keep the pepper apart from the data (HSM, environment, ...):
+// stolen ciphertext and passphrase are useless without it, but losing it makes
+// every blob encrypted with it unrecoverable.
+func WithPepper(pepper []byte) Option {
+	return func(c *Client) error {
+		c.pepper = append([]byte{}, pepper...)
+		return nil
+	}
+}
+
+// WithTagSize sets the AES-GCM authentication tag size, between 12 and 16 bytes (the default).
+// Shorter tags exist for interoperability only: each byte removed makes forgeries
+// 256 times more likely to go undetected. The size is recorded in the blob header.
+func WithTagSize(n int) Option {
+	return func(c *Client) error {
+		c.tagSize = n
+		return nil
+	}
+}
+
+// WithNonceSize sets the AES-GCM nonce size of blobs, for interoperability with
+// systems that do not use the standard 12 bytes. The size is recorded in the blob
+// header. It cannot be combined with WithTagSize, and streams keep 12-byte nonces.
+func WithNonceSize(n int) Option {
+	return func(c *Client) error {
+		if n < 1 || n > maxHeaderField {
+			return fmt.Errorf("%w: nonce size %d is outside 1-%d bytes", ErrInvalidParams, n, maxHeaderField)
+		}
+		c.nonceSize = n
+		return nil
+	}
+}
+
+// WithUniformSaltSize sets the salt size of new blobs and streams whatever the
+// security level, e.g. for storage schemas expecting fixed-size salts. The size
+// is recorded in blob and stream headers, so data of any salt size still decrypts.
+func WithUniformSaltSize(n int) Option {
+	return func(c *Client) error {
+		if n < minSaltSize || n > maxHeaderField {
+			return fmt.Errorf("%w: salt size %d is outside %d-%d bytes", ErrInvalidParams, n, minSaltSize, maxHeaderField)
+		}
+		c.saltSize = n
+		return nil
+	}
+}
+
+// WithMaxDecryptedSize bounds the plaintext DecryptStream may produce, and the
+// size compressed blobs may expand to, protecting servers that decrypt untrusted
+// data from resource exhaustion.
+// Zero, the default, means no limit.
+func WithMaxDecryptedSize(n int64) Option {
+	return func(c *Client) error {
+		if n < 0 {
+			return fmt.Errorf("%w: negative maximum decrypted size", ErrInvalidParams)
+		}
+		c.maxDecrypted = n
+		return nil
+	}
+}
+
+// WithAADMatch sets the policy used by EncryptWithAAD and DecryptWithAAD.
+func WithAADMatch(m AADMatch) Option {
+	return func(c *Client) error {
+		if err := validateAADMatch(m); err != nil {
+			return err
+		}
+		c.aadMatch = m
+		return nil
+	}
+}
+
+// WithCipher selects the AEAD used by new blobs (AES-GCM by default). The cipher
+// is recorded in the blob header; streams always use AES-GCM.
+// ChaCha20 variants require 32-byte keys and fix the nonce size.
+func WithCipher(ci Cipher) Option {
+	return func(c *Client) error {
+		if ci > CipherXChaCha20Poly1305 {
+			return fmt.Errorf("%w: unknown cipher %d", ErrInvalidParams, ci)
+		}
+		c.cipher = ci
+		return nil
+	}
+}
+
+// WithRand sets the source of random nonces (crypto/rand.Reader by default).
+// It must be a cryptographically secure generator; a predictable source
+// repeats nonces and breaks confidentiality.
+func WithRand(r io.Reader) Option {
+	return func(c *Client) error {
+		if r == nil {
+			return fmt.Errorf("%w: nil random source", ErrInvalidParams)
+		}
+		c.rand = r
+		return nil
+	}
+}
+
+// WithSaltReader sets the source of salts independently of WithRand, e.g. an
+// approved DRBG required by a compliance regime (crypto/rand.Reader by default).
+func WithSaltReader(r io.Reader) Option {
+	return func(c *Client) error {
+		if r == nil {
+			return fmt.Errorf("%w: nil salt source", ErrInvalidParams)
+		}
+		c.saltReader = r
+		return nil
+	}
+}
+
+// WithAutoThreads sets the Argon2 parallelism to GOMAXPROCS, capped at 4, when the
+// client is created, lowering derivation latency on multicore machines. The thread
+// count is recorded in blob and stream headers, so any client decrypts the result,
+// unless WithImplicitParams leaves it out.
+func WithAutoThreads() Option {
+	return func(c *Client) error {
+		c.autoThreads = true
+		return nil
+	}
+}
+
+// WithRejectBelowPolicy makes decryption refuse blobs whose recorded Argon2 memory,
+// time cost, salt or key size is below floor, with ErrPolicyViolation, forcing weak
+// data to be re-encrypted. Zero fields of floor are not enforced. Headerless legacy
+// blobs are only tried with the client's and WithDecryptFallbackParams parameters
+// meeting floor. DecryptRawWithInfo, MigrateCipher and UpgradeIfWeak still read
+// such blobs, to migrate them.
+func WithRejectBelowPolicy(floor Params) Option {
+	return func(c *Client) error {
+		c.minPolicy = &floor
+		return nil
+	}
+}
+
+// WithLevel sets the security level (SecurityStandard by default).
+func WithLevel(level SecurityLevel) Option {
+	return func(c *Client) error {
+		if _, ok := securityLevels[level]; !ok {
+			return errors.New("unknown security level")
+		}
+		c.level = level
+		return nil
+	}
+}
+
+// WithProfile sets the Argon2 profile (ProfileBalanced by default).
+func WithProfile(profile Argon2Profile) Option {
+	return func(c *Client) error {
+		if _, ok := argon2Profiles[profile]; !ok {
+			return errors.New("unknown Argon2 profile")
+		}
+		c.profile = profile
+		return nil
+	}
+}
+
+// WithLogger makes the client emit debug events to logger: key derivations with
+// their duration and cost, and decryptions failing authentication. Events never
+// contain plaintext, keys or passphrases.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) error {
+		c.logger = logger
+		return nil
+	}
+}
+
+// WithRandomPadding adds between minExtra and maxExtra bytes of random-length
+// padding to every blob, so identical plaintexts produce ciphertexts of
+// unpredictable sizes. The padding and its length are encrypted and authenticated,
+// and removed on decryption by any client. It costs 4 bytes more than the padding.
+func WithRandomPadding(minExtra, maxExtra int) Option {
+	return func(c *Client) error {
+		if err := validatePadding(minExtra, maxExtra); err != nil {
+			return err
+		}
+		c.padMin, c.padMax = minExtra, maxExtra
+		return nil
+	}
+}
+
+// WithDeterministic makes a namespace client (see NewNamespace) encrypt equal
+// plaintexts, with equal additional data, to identical blobs, e.g. to index or
+// deduplicate encrypted values. The nonce is synthesized from the plaintext instead
+// of drawn at random, which reveals when two blobs hold the same data but nothing else.
+func WithDeterministic() Option {
+	return func(c *Client) error {
+		c.deterministic = true
+		return nil
+	}
+}
+
+// WithSaltContext appends a fixed context, e.g. an application ID, to the random
+// salt of every key derivation, so clients sharing a passphrase still derive
+// unrelated keys. Only the random salt is stored: the same context must be supplied
+// to decrypt, and data encrypted under another context fails authentication.
+func WithSaltContext(context []byte) Option {
+	return func(c *Client) error {
+		c.saltContext = append([]byte{}, context...)
+		return nil
+	}
+}
+
+// WithoutBase64Padding makes Encrypt emit base64 without trailing '=' padding,
+// for database keys and systems that dislike it. Decrypt accepts both forms.
+func WithoutBase64Padding() Option {
+	return func(c *Client) error {
+		c.unpaddedBase64 = true
+		return nil
+	}
+}
+
+// WithOutputPrefix makes Encrypt prepend prefix, e.g. a tenant tag, to its output
+// and Decrypt require and strip it, returning ErrWrongPrefix otherwise. The prefix
+// is routing metadata: it is neither secret nor authenticated.
+func WithOutputPrefix(prefix string) Option {
+	return func(c *Client) error {
+		c.outputPrefix = prefix
+		return nil
+	}
+}
+
+// WithBase64Encoding makes Encrypt and Decrypt use enc instead of standard base64,
+// for legacy systems with nonstandard alphabets. Decrypt then only accepts enc,
+// whose padding must match unless WithoutBase64Padding is also given.
+func WithBase64Encoding(enc *base64.Encoding) Option {
+	return func(c *Client) error {
+		if enc == nil {
+			return fmt.Errorf("%w: nil base64 encoding", ErrInvalidParams)
+		}
+		c.customBase64 = enc
+		return nil
+	}
+}
+
+// WithNonceDerivedSubkeys makes a namespace client (see NewNamespace) seal every
+// message under its own key, HKDF(namespace key, random 32-byte nonce), with a
+// fixed GCM nonce. Per-message keys never collide in practice, lifting the limit
+// on messages random 12-byte GCM nonces impose on one key. Blobs store the 32-byte
+// nonce; any client decrypts them.
+func WithNonceDerivedSubkeys() Option {
+	return func(c *Client) error {
+		c.subkeys = true
+		return nil
+	}
+}
+
+// WithCompression sets whether plaintexts are compressed before encryption.
+// With CompressionAuto, each plaintext is gzipped and the result kept only if
+// smaller, as recorded in the blob header. Compression makes the ciphertext length
+// depend on the content: avoid it when attackers can mix their input with secrets.
+func WithCompression(mode Compression) Option {
+	return func(c *Client) error {
+		if mode != CompressionNone && mode != CompressionAuto {
+			return fmt.Errorf("%w: unknown compression mode %d", ErrInvalidParams, mode)
+		}
+		c.compression = mode
+		return nil
+	}
+}
+
+// WithDefaultAAD binds aad, e.g. an application version, to every blob sealed by
+// EncryptRaw, Encrypt and the functions built on them, and requires it to decrypt.
+// EncryptWithAAD and DecryptWithAAD use their own additional data instead.
+// Changing the default makes existing blobs fail to decrypt, by design.
+func WithDefaultAAD(aad []byte) Option {
+	return func(c *Client) error {
+		c.defaultAAD = append([]byte{}, aad...)
+		return nil
+	}
+}
+
+// WithMetrics reports blob encryptions, decryptions, authentication failures and
+// Argon2 durations to m.
+func WithMetrics(m Metrics) Option {
+	return func(c *Client) error {
+		c.metrics = m
+		return nil
+	}
+}
+
+// WithDecryptFallbackParams lists parameters to try, in order, on headerless legacy
+// blobs that do not authenticate with the client's own, e.g. the previous security
+// level during a migration. Blobs with a header record their parameters and never
+// need it. Each failed candidate costs a key derivation.
+func WithDecryptFallbackParams(candidates []Params) Option {
+	return func(c *Client) error {
+		for _, p := range candidates {
+			if err := p.validate(); err != nil {
+				return err
+			}
+		}
+		c.fallbackParams = append([]Params{}, candidates...)
+		return nil
+	}
+}
+
+// WithPlaintextChecksum stores a CRC-32C of each plaintext inside the encrypted
+// payload, checked on decryption. Authentication already guarantees integrity;
+// the checksum is a diagnostic catching a faulty encrypting side, see
+// DecryptDiagnostic. It adds 4 bytes per blob.
+func WithPlaintextChecksum() Option {
+	return func(c *Client) error {
+		c.checksum = true
+		return nil
+	}
+}
+
+// WithEncryptedFilenames makes EncryptDir encrypt file and directory names, and
+// DecryptDir decrypt them. Each name costs a key derivation, like any EncryptRaw.
+func WithEncryptedFilenames() Option {
+	return func(c *Client) error {
+		c.encryptedNames = true
+		return nil
+	}
+}
+
+// WithKeyCache keeps the keys of the last maxEntries salts derived, so that
+// decrypting the same blobs again skips Argon2. Salts stay random for every new
+// blob. Cached keys live in memory until evicted, when they are zeroed: keep the
+// cache small where memory disclosure is a concern.
+func WithKeyCache(maxEntries int) Option {
+	return func(c *Client) error {
+		if maxEntries < 1 {
+			return fmt.Errorf("%w: key cache size %d must be positive", ErrInvalidParams, maxEntries)
+		}
+		c.keyCache = newKeyCache(maxEntries)
+		return nil
+	}
+}
+
+// WithEntropyCheck runs check on every salt and nonce freshly drawn for a new
+// message, after the built-in all-zero guard, e.g. a statistical sanity test for
+// high-assurance deployments. An error from check aborts the encryption with
+// ErrWeakRandomness wrapping it. Namespace salts and derived or synthetic nonces
+// are not random and are not checked. check must be safe for concurrent use.
+func WithEntropyCheck(check func([]byte) error) Option {
+	return func(c *Client) error {
+		c.entropyCheck = check
+		return nil
+	}
+}
+
+// WithChunkSize sets the plaintext bytes per chunk of the streams the client
+// encrypts, 64 KiB by default, between 1 KiB and 16 MiB. Smaller chunks cut the
+// latency and memory of each read, larger ones the per-chunk overhead. The size
+// is recorded in the stream header, so any client decrypts the stream whatever
+// its own setting.
+func WithChunkSize(n int) Option {
+	return func(c *Client) error {
+		if n < minChunkSize || n > maxChunkSize {
+			return fmt.Errorf("%w: chunk size %d is outside %d-%d bytes", ErrInvalidParams, n, minChunkSize, maxChunkSize)
+		}
+		c.chunkSize = n
+		return nil
+	}
+}
+
+// WithImplicitParams leaves the Argon2 costs out of the blob header, for parties
+// that agree on them out of band: blobs are 9 bytes shorter and do not reveal the
+// cost to observers. The costs are still authenticated, and decryption uses the
+// client's configured ones, so a blob only decrypts with a client configured with
+// the same costs. Streams then leave out the costs too.
+func WithImplicitParams() Option {
+	return func(c *Client) error {
+		c.implicitParams = true
+		return nil
+	}
+}
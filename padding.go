@@ -0,0 +1,67 @@
+package cryptio
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+)
+
+// Padded plaintexts are data | padding (zero bytes) | padding length (uint32 BE),
+// all encrypted, so the padding length is authenticated and hidden.
+
+// maxPadding bounds WithRandomPadding so a padded blob stays reasonable in size.
+const maxPadding = 1 << 20
+
+// pad appends random padding to plaintext when WithRandomPadding is set, and
+// flags h accordingly. The caller's slice is not modified.
+func (c *Client) pad(h *header, plaintext []byte) ([]byte, error) {
+	if c.padMax == 0 {
+		return plaintext, nil
+	}
+	extra, err := rand.Int(c.rand, big.NewInt(int64(c.padMax-c.padMin+1)))
+	if err != nil {
+		return nil, err
+	}
+	n := c.padMin + int(extra.Int64())
+	padded := make([]byte, len(plaintext)+n, len(plaintext)+n+4)
+	copy(padded, plaintext)
+	h.flags |= flagPadded
+	return binary.BigEndian.AppendUint32(padded, uint32(n)), nil //nolint:gosec // at most maxPadding
+}
+
+// unpad strips the padding added by pad. The length field comes from plaintext
+// that already authenticated, but to leak nothing of the original length through
+// timing, unpad does the same work for any padding amount: it walks the whole
+// body, zeroing the padding in place and checking it was zero, without branching
+// on the length until the end.
+func unpad(padded []byte) ([]byte, error) {
+	if len(padded) < 4 {
+		return nil, ErrInvalidData
+	}
+	body := padded[:len(padded)-4]
+	n := uint64(binary.BigEndian.Uint32(padded[len(body):]))
+	// The top bit of a wrapped difference flags n > len(body); both are below 2^33.
+	tooLong := (uint64(len(body)) - n) >> 63
+	dataLen := uint64(len(body)) - n&(tooLong-1) // len(body) when tooLong
+
+	var nonZero byte
+	for i := range body {
+		keep := byte(0) - byte((uint64(i)-dataLen)>>63) // 0xFF for data bytes
+		nonZero |= body[i] &^ keep
+		body[i] &= keep
+	}
+	if tooLong|uint64(subtle.ConstantTimeByteEq(nonZero, 0)^1) != 0 {
+		return nil, ErrInvalidData
+	}
+	return body[:dataLen], nil
+}
+
+// validatePadding checks a WithRandomPadding range.
+func validatePadding(minExtra, maxExtra int) error {
+	if minExtra < 0 || maxExtra < minExtra || maxExtra > maxPadding {
+		return fmt.Errorf("%w: padding range %d-%d is not within 0-%d", ErrInvalidParams, minExtra, maxExtra, maxPadding)
+	}
+	return nil
+}
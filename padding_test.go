@@ -0,0 +1,92 @@
+package cryptio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func TestRandomPadding(t *testing.T) {
+	client, err := NewWithLevelProfile("PaddingSecret", SecurityUltraFast, ProfileBalanced, WithRandomPadding(1, 256))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	plaintext := []byte("same input every time")
+	minLen := client.CiphertextLen(len(plaintext)) + 4 + 1
+	maxLen := client.CiphertextLen(len(plaintext)) + 4 + 256
+
+	sizes := map[int]bool{}
+	for range 8 {
+		data, err := client.EncryptRaw(plaintext)
+		if err != nil {
+			t.Fatalf("EncryptRaw failed: %v", err)
+		}
+		if len(data) < minLen || len(data) > maxLen {
+			t.Errorf("Size %d is outside %d-%d", len(data), minLen, maxLen)
+		}
+		sizes[len(data)] = true
+
+		decrypted, err := client.DecryptRaw(data)
+		if err != nil {
+			t.Fatalf("DecryptRaw failed: %v", err)
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("Expected %q, got %q", plaintext, decrypted)
+		}
+	}
+	if len(sizes) < 2 {
+		t.Errorf("Expected varying sizes over 8 encryptions, got %v", sizes)
+	}
+
+	// Padded framed records still carry their exact length.
+	frame, err := client.EncryptFramed(plaintext)
+	if err != nil {
+		t.Fatalf("EncryptFramed failed: %v", err)
+	}
+	for got, err := range client.DecryptFramedStream(bytes.NewReader(frame)) {
+		if err != nil || !bytes.Equal(got, plaintext) {
+			t.Errorf("DecryptFramedStream of a padded record failed: %q, %v", got, err)
+		}
+	}
+
+	for _, r := range [][2]int{{-1, 4}, {8, 4}, {0, maxPadding + 1}} {
+		_, err := NewWithLevelProfile("PaddingSecret", SecurityUltraFast, ProfileBalanced, WithRandomPadding(r[0], r[1]))
+		if !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("Range %v: expected ErrInvalidParams, got %v", r, err)
+		}
+	}
+}
+
+// padWith pads data with exactly n bytes, as pad does.
+func padWith(data []byte, n int) []byte {
+	padded := append(bytes.Clone(data), make([]byte, n)...)
+	return binary.BigEndian.AppendUint32(padded, uint32(n)) //nolint:gosec // small test sizes
+}
+
+func TestUnpad(t *testing.T) {
+	data := []byte("authenticated data")
+	for _, n := range []int{0, 1, 15, 16, 255, 4096} {
+		got, err := unpad(padWith(data, n))
+		if err != nil {
+			t.Fatalf("unpad with %d padding bytes failed: %v", n, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("unpad with %d padding bytes: expected %q, got %q", n, data, got)
+		}
+	}
+	if got, err := unpad(padWith(nil, 8)); err != nil || len(got) != 0 {
+		t.Errorf("unpad of padding only = %q, %v; want empty", got, err)
+	}
+
+	padded := padWith(data, 8)
+	nonZero := bytes.Clone(padded)
+	nonZero[len(data)+3] = 1
+	tooLong := bytes.Clone(padded)
+	tooLong[len(tooLong)-1] = byte(len(data) + 9)
+	for name, bad := range map[string][]byte{"non-zero padding": nonZero, "length past the data": tooLong, "short": padded[:3]} {
+		if _, err := unpad(bad); !errors.Is(err, ErrInvalidData) {
+			t.Errorf("%s: expected ErrInvalidData, got %v", name, err)
+		}
+	}
+}
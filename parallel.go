@@ -0,0 +1,112 @@
+package cryptio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// EncryptReaderAt encrypts size bytes of src into dst, sealing chunks concurrently
+// on GOMAXPROCS workers. The key is derived once and every frame is written at its
+// computed offset, so the output is a regular stream readable by DecryptStream and
+// SeekableReader, laid out like the EncryptStream output except that the header
+// also records size, letting decryption detect truncation precisely.
+func (c *Client) EncryptReaderAt(dst io.WriterAt, src io.ReaderAt, size int64) error {
+	if size < 0 {
+		return fmt.Errorf("%w: negative size", ErrInvalidStream)
+	}
+	chunkSize := int64(c.encryptChunkSize())
+	chunks := max((size+chunkSize-1)/chunkSize, 1)
+	if chunks > streamMaxChunks {
+		return fmt.Errorf("%w: too many chunks", ErrInvalidStream)
+	}
+
+	flags := byte(streamFlagLength)
+	if c.streamIndex {
+		flags |= streamFlagIndex
+	}
+	p := c.currentParams()
+	header, err := c.newStreamHeader(flags, p, uint64(size))
+	if err != nil {
+		return err
+	}
+	sc, err := c.newStreamCipher(header, p)
+	if err != nil {
+		return err
+	}
+	if _, err := dst.WriteAt(header, 0); err != nil {
+		return err
+	}
+
+	frameSize := int64(4 + sc.maxSealedChunk())
+	frameOffset := func(i int64) int64 { return int64(len(header)) + i*frameSize }
+
+	workers := int(min(int64(runtime.GOMAXPROCS(0)), chunks))
+	jobs := make(chan int64)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+	}
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// chunkNonce writes into the cipher's nonce buffer: each worker needs its own.
+			w := *sc
+			w.nonce = make([]byte, len(sc.nonce))
+			chunk := make([]byte, chunkSize)
+			frame := make([]byte, 0, frameSize)
+			for i := range jobs {
+				start := i * chunkSize
+				n := int(min(size-start, chunkSize))
+				if read, err := src.ReadAt(chunk[:n], start); read < n {
+					if err == nil || errors.Is(err, io.EOF) {
+						err = io.ErrUnexpectedEOF
+					}
+					fail(err)
+					continue
+				}
+				frame = w.sealFrame(frame[:0], chunk[:n], uint32(i), i == chunks-1) //nolint:gosec // at most streamMaxChunks
+				if _, err := dst.WriteAt(frame, frameOffset(i)); err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+	for i := range chunks {
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+
+	if c.streamIndex {
+		offsets := make([]uint64, chunks)
+		for i := range offsets {
+			offsets[i] = uint64(frameOffset(int64(i))) //nolint:gosec // non-negative
+		}
+		end := frameOffset(chunks-1) + int64(4+sc.aead.Overhead()) + size - (chunks-1)*chunkSize
+		if _, err := dst.WriteAt(sc.sealIndex(nil, offsets), end); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package cryptio
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// memWriterAt is an in-memory io.WriterAt growing as needed.
+type memWriterAt struct {
+	buf []byte
+}
+
+func (m *memWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	if end := int(off) + len(p); end > len(m.buf) {
+		m.buf = append(m.buf, make([]byte, end-len(m.buf))...)
+	}
+	return copy(m.buf[off:], p), nil
+}
+
+func TestEncryptReaderAtMatchesStream(t *testing.T) {
+	for _, indexed := range []bool{false, true} {
+		var opts []Option
+		if indexed {
+			opts = append(opts, WithStreamIndex())
+		}
+		client, err := NewWithLevelProfile("ParallelSecret", SecurityUltraFast, ProfileBalanced, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		for _, size := range []int{0, 1, streamChunkSize, 3*streamChunkSize + 17} {
+			plaintext := make([]byte, size)
+			if _, err := rand.Read(plaintext); err != nil {
+				t.Fatalf("rand.Read failed: %v", err)
+			}
+			var out memWriterAt
+			if err := client.EncryptReaderAt(&out, bytes.NewReader(plaintext), int64(size)); err != nil {
+				t.Fatalf("EncryptReaderAt(%d, index=%v) failed: %v", size, indexed, err)
+			}
+			// Same layout as EncryptStream, plus the total length in the header.
+			serial := encryptTestStream(t, client, plaintext)
+			if len(out.buf) != len(serial)+8 {
+				t.Errorf("Size %d, index=%v: expected %d bytes, got %d", size, indexed, len(serial)+8, len(out.buf))
+			}
+
+			var decrypted bytes.Buffer
+			if err := client.DecryptStream(&decrypted, bytes.NewReader(out.buf)); err != nil {
+				t.Fatalf("DecryptStream(%d, index=%v) failed: %v", size, indexed, err)
+			}
+			if !bytes.Equal(decrypted.Bytes(), plaintext) {
+				t.Errorf("Size %d, index=%v: decrypted data does not match", size, indexed)
+			}
+		}
+	}
+}
+
+func TestEncryptReaderAtShortSource(t *testing.T) {
+	client, err := NewWithLevelProfile("ParallelSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	var out memWriterAt
+	err = client.EncryptReaderAt(&out, bytes.NewReader(make([]byte, 10)), 2*streamChunkSize)
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected io.ErrUnexpectedEOF for a source shorter than size, got %v", err)
+	}
+}
+
+func BenchmarkEncryptReaderAt(b *testing.B) {
+	client, err := NewWithLevelProfile("BenchSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		b.Fatalf("Failed to create client: %v", err)
+	}
+	plaintext := make([]byte, 64<<20)
+	for _, name := range []string{"Serial", "Parallel"} {
+		b.Run(fmt.Sprintf("%s-64MiB", name), func(b *testing.B) {
+			b.SetBytes(int64(len(plaintext)))
+			out := &memWriterAt{buf: make([]byte, 0, len(plaintext)+len(plaintext)/1024)}
+			for i := 0; i < b.N; i++ {
+				out.buf = out.buf[:0]
+				var err error
+				if name == "Serial" {
+					err = client.EncryptStream(io.Discard, bytes.NewReader(plaintext))
+				} else {
+					err = client.EncryptReaderAt(out, bytes.NewReader(plaintext), int64(len(plaintext)))
+				}
+				if err != nil {
+					b.Fatalf("%s encryption failed: %v", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestEncryptReaderAtDetectsTruncation(t *testing.T) {
+	client, err := NewWithLevelProfile("ParallelSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	size := 3*streamChunkSize + 17
+	var out memWriterAt
+	if err := client.EncryptReaderAt(&out, bytes.NewReader(make([]byte, size)), int64(size)); err != nil {
+		t.Fatalf("EncryptReaderAt failed: %v", err)
+	}
+	headerLen := streamHeaderSize(streamFlagParams|streamFlagSaltSize|streamFlagLength, client.params.SaltSize)
+	frameLen := 4 + streamChunkSize + gcmTagSize
+
+	for name, cut := range map[string]int{
+		"frame boundary": headerLen + 2*frameLen,
+		"mid chunk":      headerLen + frameLen + 100,
+	} {
+		err := client.DecryptStream(io.Discard, bytes.NewReader(out.buf[:cut]))
+		if !errors.Is(err, ErrTruncatedStream) || !errors.Is(err, ErrInvalidStream) {
+			t.Errorf("%s: expected ErrTruncatedStream, got %v", name, err)
+		}
+	}
+}
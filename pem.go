@@ -0,0 +1,48 @@
+package cryptio
+
+import (
+	"encoding/pem"
+	"fmt"
+	"strconv"
+)
+
+// pemType is the PEM block type of EncryptPEM output.
+const pemType = "CRYPTIO MESSAGE"
+
+// EncryptPEM encrypts plaintext and armors the blob as a PEM block, convenient to
+// paste into configuration files or emails. The PEM headers describe the cipher and
+// KDF cost for readers, the cost only when the blob records it (see
+// WithImplicitParams); they are informational only, decryption trusts the
+// authenticated blob header.
+func (c *Client) EncryptPEM(plaintext []byte) ([]byte, error) {
+	raw, err := c.EncryptRaw(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	h, _, err := readHeader(raw)
+	if err != nil {
+		return nil, err
+	}
+	headers := map[string]string{
+		"Cipher": h.cipher.String(),
+		"KDF":    "Argon2id",
+	}
+	if h.flags&flagImplicitParams == 0 {
+		headers["Argon2-Time"] = strconv.FormatUint(uint64(h.params.ArgonTime), 10)
+		headers["Argon2-Memory"] = strconv.FormatUint(uint64(h.params.ArgonMem), 10)
+		headers["Argon2-Threads"] = strconv.Itoa(int(h.params.ArgonThreads))
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: pemType, Headers: headers, Bytes: raw}), nil
+}
+
+// DecryptPEM decrypts the first PEM block of data, produced by EncryptPEM.
+func (c *Client) DecryptPEM(data []byte) ([]byte, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM block found", ErrInvalidData)
+	}
+	if block.Type != pemType {
+		return nil, fmt.Errorf("%w: unexpected PEM block type %q", ErrInvalidData, block.Type)
+	}
+	return c.DecryptRaw(block.Bytes)
+}
@@ -0,0 +1,42 @@
+package cryptio
+
+import (
+	"bytes"
+	"encoding/pem"
+	"errors"
+	"testing"
+)
+
+func TestEncryptDecryptPEM(t *testing.T) {
+	client, err := NewWithLevelProfile("PEMSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	armored, err := client.EncryptPEM([]byte("pasted secret"))
+	if err != nil {
+		t.Fatalf("EncryptPEM failed: %v", err)
+	}
+	if !bytes.HasPrefix(armored, []byte("-----BEGIN CRYPTIO MESSAGE-----\n")) {
+		t.Errorf("Unexpected PEM armor:\n%s", armored)
+	}
+	block, _ := pem.Decode(armored)
+	if block == nil || block.Headers["Cipher"] != "AES-GCM" || block.Headers["Argon2-Memory"] != "19456" {
+		t.Errorf("Unexpected PEM headers: %+v", block)
+	}
+
+	plaintext, err := client.DecryptPEM(armored)
+	if err != nil {
+		t.Fatalf("DecryptPEM failed: %v", err)
+	}
+	if string(plaintext) != "pasted secret" {
+		t.Errorf("Expected %q, got %q", "pasted secret", plaintext)
+	}
+
+	block.Type = "PRIVATE KEY"
+	if _, err := client.DecryptPEM(pem.EncodeToMemory(block)); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("Expected ErrInvalidData for a wrong PEM type, got %v", err)
+	}
+	if _, err := client.DecryptPEM([]byte("not pem")); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("Expected ErrInvalidData without a PEM block, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package cryptio
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// HashPassword hashes passphrase with Argon2id and the client's cost parameters,
+// for storing and later checking passwords rather than encrypting data. The result
+// is a standard PHC string, $argon2id$v=19$m=<KiB>,t=<time>,p=<threads>$<salt>$<hash>,
+// readable by other Argon2 implementations. The client's pepper and machine binding
+// are not applied.
+func (c *Client) HashPassword(passphrase string) (string, error) {
+	p := c.currentParams()
+	salt := make([]byte, p.SaltSize)
+	if err := c.readFresh(c.saltReader, salt); err != nil {
+		return "", err
+	}
+	if err := checkArgon2Memory(p); err != nil {
+		return "", err
+	}
+	hash := argon2.IDKey([]byte(passphrase), salt, p.ArgonTime, p.ArgonMem, p.ArgonThreads, p.KeySize)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.ArgonMem, p.ArgonTime, p.ArgonThreads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash),
+	), nil
+}
+
+// VerifyPassword reports whether passphrase matches a PHC string produced by
+// HashPassword or any Argon2id implementation. The cost is taken from the string,
+// within the same limits as blob headers; a malformed string returns an error.
+func (c *Client) VerifyPassword(passphrase, phc string) (bool, error) {
+	fields := strings.Split(phc, "$")
+	if len(fields) != 6 || fields[0] != "" || fields[1] != "argon2id" {
+		return false, fmt.Errorf("%w: not an argon2id PHC string", ErrInvalidData)
+	}
+	var version int
+	if _, err := fmt.Sscanf(fields[2], "v=%d", &version); err != nil {
+		return false, fmt.Errorf("%w: invalid PHC version", ErrInvalidData)
+	}
+	if version != argon2.Version {
+		return false, fmt.Errorf("%w: hash uses version %#x, this library implements %#x", ErrArgon2VersionMismatch, version, argon2.Version)
+	}
+	var mem, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(fields[3], "m=%d,t=%d,p=%d", &mem, &time, &threads); err != nil {
+		return false, fmt.Errorf("%w: invalid PHC parameters", ErrInvalidData)
+	}
+	if time < 1 || threads < 1 || mem < 8*uint32(threads) {
+		return false, fmt.Errorf("%w: invalid PHC parameters", ErrInvalidData)
+	}
+	if mem > maxHeaderArgonMem || time > maxHeaderArgonTime {
+		return false, fmt.Errorf("%w: PHC string requests an excessive Argon2 cost", ErrInvalidData)
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(fields[4])
+	if err != nil || len(salt) == 0 {
+		return false, fmt.Errorf("%w: invalid PHC salt", ErrInvalidData)
+	}
+	hash, err := base64.RawStdEncoding.DecodeString(fields[5])
+	if err != nil || len(hash) < minExternalKeySize {
+		return false, fmt.Errorf("%w: invalid PHC hash", ErrInvalidData)
+	}
+
+	if err := checkArgon2Memory(Params{ArgonMem: mem}); err != nil {
+		return false, err
+	}
+	computed := argon2.IDKey([]byte(passphrase), salt, time, mem, threads, uint32(len(hash))) //nolint:gosec // bounded by the string length
+	return subtle.ConstantTimeCompare(computed, hash) == 1, nil
+}
@@ -0,0 +1,66 @@
+package cryptio
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// knownPHC is the argon2id test vector of the reference implementation (phc-winner-argon2).
+const knownPHC = "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"
+
+func TestVerifyPasswordKnownVector(t *testing.T) {
+	client, err := NewWithLevelProfile("unused", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	ok, err := client.VerifyPassword("password", knownPHC)
+	if err != nil {
+		t.Fatalf("VerifyPassword failed: %v", err)
+	}
+	if !ok {
+		t.Error("VerifyPassword should accept the reference vector")
+	}
+	ok, err = client.VerifyPassword("wrong password", knownPHC)
+	if err != nil {
+		t.Fatalf("VerifyPassword failed: %v", err)
+	}
+	if ok {
+		t.Error("VerifyPassword should reject a wrong password")
+	}
+}
+
+func TestHashPassword(t *testing.T) {
+	client, err := NewWithLevelProfile("unused", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	phc, err := client.HashPassword("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("HashPassword failed: %v", err)
+	}
+	if !strings.HasPrefix(phc, "$argon2id$v=19$m=19456,t=2,p=1$") {
+		t.Errorf("Unexpected PHC string %q", phc)
+	}
+	if ok, err := client.VerifyPassword("correct horse battery staple", phc); err != nil || !ok {
+		t.Errorf("VerifyPassword should accept the hashed password: %v, %v", ok, err)
+	}
+	if ok, err := client.VerifyPassword("Correct horse battery staple", phc); err != nil || ok {
+		t.Errorf("VerifyPassword should reject a wrong password: %v, %v", ok, err)
+	}
+
+	for _, bad := range []string{
+		"",
+		"$argon2i$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$argon2id$v=19$m=65536,t=0,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$argon2id$v=19$m=65536,t=2,p=1$!!$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$argon2id$v=19$m=99999999,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+	} {
+		if _, err := client.VerifyPassword("password", bad); !errors.Is(err, ErrInvalidData) {
+			t.Errorf("Expected ErrInvalidData for %q, got %v", bad, err)
+		}
+	}
+	if _, err := client.VerifyPassword("password", strings.Replace(knownPHC, "v=19", "v=16", 1)); !errors.Is(err, ErrArgon2VersionMismatch) {
+		t.Errorf("Expected ErrArgon2VersionMismatch, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package cryptio
+
+import "fmt"
+
+// Preset bundles a security level, an Argon2 profile and a cipher for a common
+// use, for callers who would rather not pick each one.
+type Preset int
+
+const (
+	PresetInteractive Preset = iota // Standard/Balanced, AES-GCM: m=64 MiB, t=2, p=1, for logins and unlocking
+	PresetServer                    // Medium/Balanced, AES-GCM: m=128 MiB, t=3, p=2, for services encrypting records
+	PresetArchive                   // High/RAMHeavy, XChaCha20-Poly1305: m=256 MiB, t=4, p=2, for long-term storage
+)
+
+func (pr Preset) String() string {
+	switch pr {
+	case PresetInteractive:
+		return "Interactive"
+	case PresetServer:
+		return "Server"
+	case PresetArchive:
+		return "Archive"
+	default:
+		return "Unknown"
+	}
+}
+
+// presetBundle is what a Preset stands for.
+type presetBundle struct {
+	level   SecurityLevel
+	profile Argon2Profile
+	cipher  Cipher
+}
+
+var presets = map[Preset]presetBundle{
+	PresetInteractive: {SecurityStandard, ProfileBalanced, CipherAESGCM},
+	PresetServer:      {SecurityMedium, ProfileBalanced, CipherAESGCM},
+	// Archives outlive hardware: XChaCha20's random nonces never need counting,
+	// and it does not depend on AES acceleration wherever the data is restored.
+	PresetArchive: {SecurityHigh, ProfileRAMHeavy, CipherXChaCha20Poly1305},
+}
+
+// NewPreset creates a client configured by preset, like NewWithLevelProfile with
+// the level, profile and cipher it stands for. opts may refine it further.
+func NewPreset(passphrase string, preset Preset, opts ...Option) (*Client, error) {
+	b, ok := presets[preset]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown preset %d", ErrInvalidParams, preset)
+	}
+	return NewWithLevelProfile(passphrase, b.level, b.profile, append([]Option{WithCipher(b.cipher)}, opts...)...)
+}
@@ -0,0 +1,43 @@
+package cryptio
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewPreset(t *testing.T) {
+	for _, tc := range []struct {
+		preset  Preset
+		mem     uint32
+		time    uint32
+		threads uint8
+		cipher  Cipher
+	}{
+		{PresetInteractive, 64 * 1024, 2, 1, CipherAESGCM},
+		{PresetServer, 128 * 1024, 3, 2, CipherAESGCM},
+		{PresetArchive, 256 * 1024, 4, 2, CipherXChaCha20Poly1305},
+	} {
+		client, err := NewPreset("PresetSecret", tc.preset)
+		if err != nil {
+			t.Fatalf("%v: NewPreset failed: %v", tc.preset, err)
+		}
+		p := client.params
+		if p.ArgonMem != tc.mem || p.ArgonTime != tc.time || p.ArgonThreads != tc.threads || client.cipher != tc.cipher {
+			t.Errorf("%v: got m=%d t=%d p=%d %v, want m=%d t=%d p=%d %v", tc.preset,
+				p.ArgonMem, p.ArgonTime, p.ArgonThreads, client.cipher, tc.mem, tc.time, tc.threads, tc.cipher)
+		}
+	}
+
+	// Options apply on top of the preset.
+	client, err := NewPreset("PresetSecret", PresetArchive, WithCipher(CipherAESGCM))
+	if err != nil {
+		t.Fatalf("NewPreset with options failed: %v", err)
+	}
+	if client.cipher != CipherAESGCM {
+		t.Errorf("Expected the WithCipher option to override the preset, got %v", client.cipher)
+	}
+
+	if _, err := NewPreset("PresetSecret", Preset(42)); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Expected ErrInvalidParams for an unknown preset, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package cryptio
+
+import (
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+)
+
+// Public-key format:
+//
+//	magic "CRYK" (4) | version (1) | ephemeral X25519 public key (32)
+//	| AES-256-GCM ciphertext
+//
+// The key is HKDF-SHA256 of the X25519 shared secret, salted with the ephemeral
+// and recipient public keys. Every message has its own ephemeral key pair, hence
+// its own AES key, so the GCM nonce is fixed at zero and not stored. Everything
+// before the ciphertext is authenticated as additional data.
+
+var pubkeyMagic = []byte("CRYK")
+
+const (
+	pubkeyVersion    = 1
+	pubkeyHeaderSize = 5 + 32
+)
+
+// EncryptForPublicKey encrypts plaintext so that only the holder of the private
+// key matching recipient, an X25519 key, can decrypt it with
+// DecryptWithPrivateKey. No passphrase or Argon2 run is involved: the shared
+// secret of an ephemeral key pair has full entropy and needs no stretching.
+// The sender is not authenticated: anyone with recipient can produce such data.
+func EncryptForPublicKey(plaintext []byte, recipient *ecdh.PublicKey) ([]byte, error) {
+	if recipient.Curve() != ecdh.X25519() {
+		return nil, fmt.Errorf("%w: recipient key must be an X25519 key", ErrInvalidParams)
+	}
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRandomnessUnavailable, err)
+	}
+	out := make([]byte, 0, pubkeyHeaderSize+len(plaintext)+gcmTagSize)
+	out = append(out, pubkeyMagic...)
+	out = append(out, pubkeyVersion)
+	out = append(out, ephemeral.PublicKey().Bytes()...)
+	gcm, err := pubkeyAEAD(ephemeral, recipient, out[len(pubkeyMagic)+1:], recipient.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Seal(out, make([]byte, gcmNonceSize), plaintext, out), nil
+}
+
+// DecryptWithPrivateKey decrypts data produced by EncryptForPublicKey for the
+// public key of priv.
+func DecryptWithPrivateKey(data []byte, priv *ecdh.PrivateKey) ([]byte, error) {
+	if priv.Curve() != ecdh.X25519() {
+		return nil, fmt.Errorf("%w: private key must be an X25519 key", ErrInvalidParams)
+	}
+	if len(data) < pubkeyHeaderSize+gcmTagSize || string(data[:len(pubkeyMagic)]) != string(pubkeyMagic) {
+		return nil, fmt.Errorf("%w: not public-key encrypted data", ErrInvalidData)
+	}
+	if data[len(pubkeyMagic)] != pubkeyVersion {
+		return nil, fmt.Errorf("%w: unsupported public-key format version %d", ErrInvalidData, data[len(pubkeyMagic)])
+	}
+	ephemeral, err := ecdh.X25519().NewPublicKey(data[len(pubkeyMagic)+1 : pubkeyHeaderSize])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidData, err)
+	}
+	gcm, err := pubkeyAEAD(priv, ephemeral, ephemeral.Bytes(), priv.PublicKey().Bytes())
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, make([]byte, gcmNonceSize), data[pubkeyHeaderSize:], data[:pubkeyHeaderSize])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecryptFailed, err)
+	}
+	return plaintext, nil
+}
+
+// pubkeyAEAD returns the AES-256-GCM instance keyed by the shared secret of priv
+// and peer, for the message from the ephemeral key ephemeralPub to recipientPub.
+func pubkeyAEAD(priv *ecdh.PrivateKey, peer *ecdh.PublicKey, ephemeralPub, recipientPub []byte) (cipher.AEAD, error) {
+	shared, err := priv.ECDH(peer)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyDerivation, err)
+	}
+	defer clear(shared)
+	salt := append(append(make([]byte, 0, len(ephemeralPub)+len(recipientPub)), ephemeralPub...), recipientPub...)
+	key, err := hkdf.Key(sha256.New, shared, salt, "cryptio x25519", envelopeDEKSize)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyDerivation, err)
+	}
+	defer clear(key)
+	return envelopeAEAD(key)
+}
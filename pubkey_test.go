@@ -0,0 +1,49 @@
+package cryptio
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestEncryptForPublicKey(t *testing.T) {
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	data, err := EncryptForPublicKey([]byte("for your eyes only"), priv.PublicKey())
+	if err != nil {
+		t.Fatalf("EncryptForPublicKey failed: %v", err)
+	}
+	plaintext, err := DecryptWithPrivateKey(data, priv)
+	if err != nil {
+		t.Fatalf("DecryptWithPrivateKey failed: %v", err)
+	}
+	if string(plaintext) != "for your eyes only" {
+		t.Errorf("Expected %q, got %q", "for your eyes only", plaintext)
+	}
+
+	other, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	if _, err := DecryptWithPrivateKey(data, other); !errors.Is(err, ErrDecryptFailed) {
+		t.Errorf("Expected ErrDecryptFailed with another private key, got %v", err)
+	}
+	data[len(pubkeyMagic)+1] ^= 1 // ephemeral public key
+	if _, err := DecryptWithPrivateKey(data, priv); err == nil {
+		t.Error("Decrypting with a tampered ephemeral key should fail, but did not")
+	}
+	if _, err := DecryptWithPrivateKey(data[:pubkeyHeaderSize], priv); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("Expected ErrInvalidData for truncated data, got %v", err)
+	}
+
+	p256, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	if _, err := EncryptForPublicKey([]byte("x"), p256.PublicKey()); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Expected ErrInvalidParams for a P-256 key, got %v", err)
+	}
+}
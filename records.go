@@ -0,0 +1,114 @@
+package cryptio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"os"
+)
+
+// Record files are a sequence of frames: length (uint32 BE) | EncryptRaw blob.
+// Every record has its own salt and nonce, so it can be verified on its own and
+// appending never requires rewriting what is already in the file.
+
+// AppendRecord encrypts plaintext and appends it as a new record at the end of file.
+// The record is written with a single Write call.
+func (c *Client) AppendRecord(file *os.File, plaintext []byte) error {
+	blob, err := c.EncryptRaw(plaintext)
+	if err != nil {
+		return err
+	}
+	if uint64(len(blob)) > math.MaxUint32 {
+		return errors.New("record too large")
+	}
+	frame := make([]byte, 0, 4+len(blob))
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(blob))) //nolint:gosec // checked above
+	frame = append(frame, blob...)
+
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+	_, err = file.Write(frame)
+	return err
+}
+
+// ReadRecords returns an iterator over the decrypted records read from r.
+// Iteration stops after the first error, which is yielded with a nil record.
+func (c *Client) ReadRecords(r io.Reader) iter.Seq2[[]byte, error] {
+	return readFrames(r, func(_, blob []byte) ([]byte, error) {
+		return c.DecryptRaw(blob)
+	})
+}
+
+// EncryptFramed encrypts plaintext as a self-delimiting record,
+// length (uint32 BE) | blob, where the length is authenticated as additional data,
+// along with the WithDefaultAAD additional data, if any. Framed records can be
+// concatenated and read back with DecryptFramedStream.
+func (c *Client) EncryptFramed(plaintext []byte) ([]byte, error) {
+	h := c.newHeader()
+	plaintext, err := c.encodePlaintext(&h, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	length := h.minBlobSize() + len(plaintext)
+	if uint64(length) > math.MaxUint32 {
+		return nil, errors.New("record too large")
+	}
+	frame := make([]byte, 0, 4+length)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(length)) //nolint:gosec // checked above
+	return c.sealHeader(frame, plaintext, c.framedAAD(frame), h)
+}
+
+// DecryptFramedStream returns an iterator over the plaintexts of the records
+// produced by EncryptFramed and concatenated in r.
+// Iteration stops after the first error, which is yielded with a nil record.
+func (c *Client) DecryptFramedStream(r io.Reader) iter.Seq2[[]byte, error] {
+	return readFrames(r, func(prefix, blob []byte) ([]byte, error) {
+		return c.open(blob, c.framedAAD(prefix))
+	})
+}
+
+// framedAAD returns the additional data of a framed record with the given
+// length prefix: the prefix, followed by the default additional data.
+func (c *Client) framedAAD(prefix []byte) []byte {
+	if len(c.defaultAAD) == 0 {
+		return prefix
+	}
+	return append(prefix[:len(prefix):len(prefix)], c.defaultAAD...)
+}
+
+// readFrames iterates over length-prefixed frames read from r, decrypting each
+// blob with open, which also receives the length prefix.
+func readFrames(r io.Reader, open func(prefix, blob []byte) ([]byte, error)) iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		var lenBuf [4]byte
+		var buf bytes.Buffer
+		for index := 0; ; index++ {
+			if _, err := io.ReadFull(r, lenBuf[:]); errors.Is(err, io.EOF) {
+				return
+			} else if err != nil {
+				yield(nil, fmt.Errorf("record %d: truncated length: %w", index, err))
+				return
+			}
+			// Grow the buffer as data arrives rather than trusting the length upfront.
+			buf.Reset()
+			length := int64(binary.BigEndian.Uint32(lenBuf[:]))
+			if n, err := io.CopyN(&buf, r, length); err != nil {
+				yield(nil, fmt.Errorf("record %d: truncated after %d of %d bytes: %w", index, n, length, err))
+				return
+			}
+			plaintext, err := open(lenBuf[:], buf.Bytes())
+			if err != nil {
+				yield(nil, fmt.Errorf("record %d: %w", index, err))
+				return
+			}
+			if !yield(plaintext, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package cryptio
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestAppendAndReadRecords(t *testing.T) {
+	client, err := NewWithLevelProfile("AuditLog", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "audit.log")
+	records := []string{"user alice logged in", "user bob changed role", "user alice logged out"}
+
+	// Each record is appended through a separately opened file.
+	for _, record := range records {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+		if err != nil {
+			t.Fatalf("OpenFile failed: %v", err)
+		}
+		if err := client.AppendRecord(f, []byte(record)); err != nil {
+			t.Fatalf("AppendRecord failed: %v", err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	var got []string
+	for record, err := range client.ReadRecords(f) {
+		if err != nil {
+			t.Fatalf("ReadRecords failed: %v", err)
+		}
+		got = append(got, string(record))
+	}
+	if len(got) != len(records) {
+		t.Fatalf("Expected %d records, got %d", len(records), len(got))
+	}
+	for i := range records {
+		if got[i] != records[i] {
+			t.Errorf("Record %d: expected %q, got %q", i, records[i], got[i])
+		}
+	}
+}
+
+func TestReadRecordsTampered(t *testing.T) {
+	client, err := NewWithLevelProfile("AuditLog", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "audit.log")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := client.AppendRecord(f, []byte("first")); err != nil {
+		t.Fatalf("AppendRecord failed: %v", err)
+	}
+	f.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	data[len(data)-1] ^= 0x01
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	f, err = os.Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	for _, err := range client.ReadRecords(f) {
+		if err == nil {
+			t.Error("ReadRecords should fail on a tampered record, but did not")
+		}
+	}
+}
+
+func TestEncryptFramedRecords(t *testing.T) {
+	client, err := NewWithLevelProfile("FramedSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	records := []string{"first", "", "third record"}
+	var stream bytes.Buffer
+	for _, record := range records {
+		frame, err := client.EncryptFramed([]byte(record))
+		if err != nil {
+			t.Fatalf("EncryptFramed failed: %v", err)
+		}
+		stream.Write(frame)
+	}
+	data := stream.Bytes()
+
+	var got []string
+	for plaintext, err := range client.DecryptFramedStream(bytes.NewReader(data)) {
+		if err != nil {
+			t.Fatalf("DecryptFramedStream failed: %v", err)
+		}
+		got = append(got, string(plaintext))
+	}
+	if !slices.Equal(got, records) {
+		t.Errorf("Expected records %q, got %q", records, got)
+	}
+
+	// Re-framing the first blob with a different length breaks its authentication.
+	first := client.CiphertextLen(len(records[0]))
+	reframed := append([]byte{0, 0, 0, byte(first + 1)}, data[4:4+first]...)
+	reframed = append(reframed, 0)
+	for _, err := range client.DecryptFramedStream(bytes.NewReader(reframed)) {
+		if err == nil {
+			t.Error("A record with an altered length should fail, but did not")
+		}
+	}
+}
+
+func TestEncryptFramedOptions(t *testing.T) {
+	client, err := NewWithLevelProfile("FramedSecret", SecurityUltraFast, ProfileBalanced,
+		WithPlaintextChecksum(), WithDefaultAAD([]byte("tenant-a")))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	frame, err := client.EncryptFramed([]byte("checked record"))
+	if err != nil {
+		t.Fatalf("EncryptFramed failed: %v", err)
+	}
+	h, _, err := readHeader(frame[4:])
+	if err != nil {
+		t.Fatalf("readHeader failed: %v", err)
+	}
+	if h.flags&flagChecksum == 0 {
+		t.Error("Framed record should carry the plaintext checksum")
+	}
+	for plaintext, err := range client.DecryptFramedStream(bytes.NewReader(frame)) {
+		if err != nil || string(plaintext) != "checked record" {
+			t.Errorf("DecryptFramedStream = %q, %v", plaintext, err)
+		}
+	}
+
+	other, err := NewWithLevelProfile("FramedSecret", SecurityUltraFast, ProfileBalanced, WithDefaultAAD([]byte("tenant-b")))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	for _, err := range other.DecryptFramedStream(bytes.NewReader(frame)) {
+		if !errors.Is(err, ErrDecryptFailed) {
+			t.Errorf("Expected ErrDecryptFailed with other default additional data, got %v", err)
+		}
+	}
+}
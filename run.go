@@ -0,0 +1,37 @@
+package cryptio
+
+import (
+	"encoding/base64"
+	"io"
+)
+
+// Config describes the client used by RunEncrypt and RunDecrypt.
+type Config struct {
+	Passphrase string
+	Level      SecurityLevel
+	Profile    Argon2Profile
+}
+
+// RunEncrypt reads plaintext from in and writes the base64-encoded encrypted stream to out.
+// It is the building block of a command-line tool reading stdin and writing stdout.
+func RunEncrypt(cfg Config, in io.Reader, out io.Writer) error {
+	client, err := NewWithLevelProfile(cfg.Passphrase, cfg.Level, cfg.Profile)
+	if err != nil {
+		return err
+	}
+	enc := base64.NewEncoder(base64.StdEncoding, out)
+	if err := client.EncryptStream(enc, in); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// RunDecrypt reads a base64-encoded encrypted stream from in and writes the plaintext to out.
+// Newlines in the input are ignored, so wrapped base64 is accepted.
+func RunDecrypt(cfg Config, in io.Reader, out io.Writer) error {
+	client, err := NewWithLevelProfile(cfg.Passphrase, cfg.Level, cfg.Profile)
+	if err != nil {
+		return err
+	}
+	return client.DecryptStream(out, base64.NewDecoder(base64.StdEncoding, in))
+}
@@ -0,0 +1,47 @@
+package cryptio
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestRunEncryptDecrypt(t *testing.T) {
+	cfg := Config{
+		Passphrase: "CLISecret",
+		Level:      SecurityUltraFast,
+		Profile:    ProfileBalanced,
+	}
+	plaintext := strings.Repeat("line of text from stdin\n", 5000)
+
+	var encrypted bytes.Buffer
+	if err := RunEncrypt(cfg, strings.NewReader(plaintext), &encrypted); err != nil {
+		t.Fatalf("RunEncrypt failed: %v", err)
+	}
+	if _, err := base64.StdEncoding.DecodeString(encrypted.String()); err != nil {
+		t.Fatalf("RunEncrypt output is not valid base64: %v", err)
+	}
+
+	var decrypted bytes.Buffer
+	if err := RunDecrypt(cfg, &encrypted, &decrypted); err != nil {
+		t.Fatalf("RunDecrypt failed: %v", err)
+	}
+	if decrypted.String() != plaintext {
+		t.Error("RunDecrypt output does not match the original plaintext")
+	}
+}
+
+func TestRunDecryptWrongPassphrase(t *testing.T) {
+	cfg := Config{Passphrase: "CLISecret", Level: SecurityUltraFast, Profile: ProfileBalanced}
+
+	var encrypted bytes.Buffer
+	if err := RunEncrypt(cfg, strings.NewReader("secret"), &encrypted); err != nil {
+		t.Fatalf("RunEncrypt failed: %v", err)
+	}
+	cfg.Passphrase = "Other"
+	var decrypted bytes.Buffer
+	if err := RunDecrypt(cfg, &encrypted, &decrypted); err == nil {
+		t.Error("RunDecrypt should fail with a different passphrase, but did not")
+	}
+}
@@ -0,0 +1,214 @@
+package cryptio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// SeekableReader decrypts a stream produced by EncryptStream with random access.
+// Only the chunk holding the current position is read and authenticated.
+type SeekableReader struct {
+	sc       *streamCipher
+	src      io.ReadSeeker
+	offsets  []uint64 // offset of each frame from the start of the stream
+	size     int64    // total plaintext size
+	pos      int64
+	chunk    []byte // decrypted content of chunk chunkIdx
+	chunkIdx int
+	sealed   []byte
+}
+
+// NewSeekableReader returns a reader over the plaintext of the encrypted stream in src.
+// Streams written with WithStreamIndex are located in constant time through their
+// trailing index; others are scanned frame by frame once, when the reader is created.
+func (c *Client) NewSeekableReader(src io.ReadSeeker) (*SeekableReader, error) {
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	sc, err := c.readStreamHeader(src)
+	if err != nil {
+		return nil, err
+	}
+	r := &SeekableReader{
+		sc:       sc,
+		src:      src,
+		chunkIdx: -1,
+		sealed:   make([]byte, sc.maxSealedChunk()),
+	}
+	if sc.flags&streamFlagIndex != 0 {
+		r.offsets, err = r.readIndex()
+	} else {
+		r.offsets, err = r.scanFrames()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	last := len(r.offsets) - 1
+	lastSize, _, err := r.readFramePrefix(last)
+	if err != nil {
+		return nil, err
+	}
+	r.size = int64(last)*int64(sc.chunkSize) + int64(lastSize-sc.aead.Overhead())
+	return r, nil
+}
+
+// readIndex loads the sealed chunk index from the end of the stream.
+func (r *SeekableReader) readIndex() ([]uint64, error) {
+	end, err := r.src.Seek(-4, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing index", ErrInvalidStream)
+	}
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(r.src, lenBuf[:]); err != nil {
+		return nil, fmt.Errorf("%w: missing index", ErrInvalidStream)
+	}
+	indexLen := int64(binary.BigEndian.Uint32(lenBuf[:]))
+	if indexLen > end-int64(len(r.sc.header)) {
+		return nil, fmt.Errorf("%w: invalid index length", ErrInvalidStream)
+	}
+	if _, err := r.src.Seek(end-indexLen, io.SeekStart); err != nil {
+		return nil, err
+	}
+	sealed := make([]byte, indexLen)
+	if _, err := io.ReadFull(r.src, sealed); err != nil {
+		return nil, fmt.Errorf("%w: truncated index", ErrInvalidStream)
+	}
+	return r.sc.openIndex(sealed)
+}
+
+// scanFrames walks the frame length prefixes to locate every chunk.
+func (r *SeekableReader) scanFrames() ([]uint64, error) {
+	var offsets []uint64
+	offset := uint64(len(r.sc.header))
+	var lenBuf [4]byte
+	for counter := uint32(0); ; counter++ {
+		if _, err := io.ReadFull(r.src, lenBuf[:]); err != nil {
+			return nil, fmt.Errorf("%w: truncated before final chunk", ErrInvalidStream)
+		}
+		size, final, err := r.sc.parseFrameLength(lenBuf[:], counter)
+		if err != nil {
+			return nil, err
+		}
+		offsets = append(offsets, offset)
+		if final {
+			return offsets, nil
+		}
+		if counter == streamMaxChunks-1 {
+			return nil, fmt.Errorf("%w: too many chunks", ErrInvalidStream)
+		}
+		if _, err := r.src.Seek(int64(size), io.SeekCurrent); err != nil {
+			return nil, err
+		}
+		offset += uint64(4 + size)
+	}
+}
+
+// readFramePrefix seeks to chunk idx and reads its length prefix.
+func (r *SeekableReader) readFramePrefix(idx int) (int, bool, error) {
+	if _, err := r.src.Seek(int64(r.offsets[idx]), io.SeekStart); err != nil { //nolint:gosec // authenticated offset
+		return 0, false, err
+	}
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(r.src, lenBuf[:]); err != nil {
+		return 0, false, fmt.Errorf("%w: chunk %d is truncated", ErrInvalidStream, idx)
+	}
+	size, final, err := r.sc.parseFrameLength(lenBuf[:], uint32(idx)) //nolint:gosec // idx < streamMaxChunks
+	if err != nil {
+		return 0, false, err
+	}
+	if final != (idx == len(r.offsets)-1) {
+		return 0, false, fmt.Errorf("%w: unexpected final flag on chunk %d", ErrInvalidStream, idx)
+	}
+	return size, final, nil
+}
+
+// loadChunk reads, authenticates and decrypts chunk idx.
+func (r *SeekableReader) loadChunk(idx int) error {
+	if idx == r.chunkIdx {
+		return nil
+	}
+	size, final, err := r.readFramePrefix(idx)
+	if err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(r.src, r.sealed[:size]); err != nil {
+		return fmt.Errorf("%w: chunk %d is truncated", ErrInvalidStream, idx)
+	}
+	r.chunk, err = r.sc.openChunk(r.chunk[:0], r.sealed[:size], uint32(idx), final) //nolint:gosec // idx < streamMaxChunks
+	if err != nil {
+		r.chunkIdx = -1
+		return err
+	}
+	if !final && len(r.chunk) != r.sc.chunkSize {
+		r.chunkIdx = -1
+		return fmt.Errorf("%w: chunk %d is not full", ErrInvalidStream, idx)
+	}
+	r.chunkIdx = idx
+	return nil
+}
+
+// Size returns the total plaintext size of the stream.
+func (r *SeekableReader) Size() int64 {
+	return r.size
+}
+
+// Read implements io.Reader.
+func (r *SeekableReader) Read(p []byte) (int, error) {
+	if r.pos >= r.size {
+		return 0, io.EOF
+	}
+	chunkSize := int64(r.sc.chunkSize)
+	if err := r.loadChunk(int(r.pos / chunkSize)); err != nil {
+		return 0, err
+	}
+	n := copy(p, r.chunk[r.pos%chunkSize:])
+	r.pos += int64(n)
+	return n, nil
+}
+
+// Seek implements io.Seeker over the plaintext.
+func (r *SeekableReader) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = r.pos + offset
+	case io.SeekEnd:
+		abs = r.size + offset
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("negative position")
+	}
+	r.pos = abs
+	return abs, nil
+}
+
+// DecryptStreamFrom decrypts the stream in src from chunk startChunk onward, each
+// chunk holding the chunk size of the stream (64 KiB unless written with
+// WithChunkSize) of plaintext, e.g. to resume an interrupted decryption.
+// src must implement io.Seeker; the chunk is located through the stream index
+// when there is one. Every chunk written is authenticated, as with DecryptStream.
+func (c *Client) DecryptStreamFrom(dst io.Writer, src io.Reader, startChunk uint64) error {
+	rs, ok := src.(io.ReadSeeker)
+	if !ok {
+		return errors.New("DecryptStreamFrom requires a seekable source")
+	}
+	r, err := c.NewSeekableReader(rs)
+	if err != nil {
+		return err
+	}
+	if startChunk >= uint64(len(r.offsets)) {
+		return fmt.Errorf("%w: start chunk %d is past the %d chunks of the stream", ErrInvalidStream, startChunk, len(r.offsets))
+	}
+	if _, err := r.Seek(int64(startChunk)*int64(r.sc.chunkSize), io.SeekStart); err != nil { //nolint:gosec // below the chunk count
+		return err
+	}
+	_, err = io.Copy(dst, r)
+	return err
+}
@@ -0,0 +1,148 @@
+package cryptio
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+)
+
+// countingReadSeeker counts the reads issued against the underlying stream.
+type countingReadSeeker struct {
+	io.ReadSeeker
+	reads int
+}
+
+func (c *countingReadSeeker) Read(p []byte) (int, error) {
+	c.reads++
+	return c.ReadSeeker.Read(p)
+}
+
+func encryptTestStream(t *testing.T, client *Client, plaintext []byte) []byte {
+	t.Helper()
+	var encrypted bytes.Buffer
+	if err := client.EncryptStream(&encrypted, bytes.NewReader(plaintext)); err != nil {
+		t.Fatalf("EncryptStream failed: %v", err)
+	}
+	return encrypted.Bytes()
+}
+
+func TestSeekableReaderWithIndex(t *testing.T) {
+	client, err := NewWithLevelProfile("SeekSecret", SecurityUltraFast, ProfileBalanced, WithStreamIndex())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	plaintext := make([]byte, 64*streamChunkSize+123)
+	if _, err := rand.Read(plaintext); err != nil {
+		t.Fatalf("rand.Read failed: %v", err)
+	}
+	data := encryptTestStream(t, client, plaintext)
+
+	// DecryptStream verifies the index too.
+	var decrypted bytes.Buffer
+	if err := client.DecryptStream(&decrypted, bytes.NewReader(data)); err != nil {
+		t.Fatalf("DecryptStream failed: %v", err)
+	}
+	if !bytes.Equal(plaintext, decrypted.Bytes()) {
+		t.Fatal("Indexed stream did not round-trip through DecryptStream")
+	}
+
+	src := &countingReadSeeker{ReadSeeker: bytes.NewReader(data)}
+	r, err := client.NewSeekableReader(src)
+	if err != nil {
+		t.Fatalf("NewSeekableReader failed: %v", err)
+	}
+	if r.Size() != int64(len(plaintext)) {
+		t.Fatalf("Expected size %d, got %d", len(plaintext), r.Size())
+	}
+	if _, err := r.Seek(-200, io.SeekEnd); err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	tail, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if !bytes.Equal(plaintext[len(plaintext)-200:], tail) {
+		t.Error("Tail read through the index does not match the plaintext")
+	}
+	// Header, index, last frame prefix, then the two chunks spanned by the tail.
+	if src.reads > 10 {
+		t.Errorf("Expected a constant number of reads with the index, got %d", src.reads)
+	}
+}
+
+func TestSeekableReaderWithoutIndex(t *testing.T) {
+	client, err := NewWithLevelProfile("SeekSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	plaintext := bytes.Repeat([]byte("0123456789"), streamChunkSize)
+	data := encryptTestStream(t, client, plaintext)
+
+	src := &countingReadSeeker{ReadSeeker: bytes.NewReader(data)}
+	r, err := client.NewSeekableReader(src)
+	if err != nil {
+		t.Fatalf("NewSeekableReader failed: %v", err)
+	}
+	if src.reads < len(plaintext)/streamChunkSize {
+		t.Errorf("Expected a scan of every frame, got %d reads", src.reads)
+	}
+
+	buf := make([]byte, 25)
+	if _, err := r.Seek(int64(streamChunkSize-10), io.SeekStart); err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	if _, err := io.ReadFull(r, buf); err != nil {
+		t.Fatalf("ReadFull across a chunk boundary failed: %v", err)
+	}
+	if !bytes.Equal(plaintext[streamChunkSize-10:streamChunkSize+15], buf) {
+		t.Error("Read across a chunk boundary does not match the plaintext")
+	}
+}
+
+func TestSeekableReaderRejectsTamperedIndex(t *testing.T) {
+	client, err := NewWithLevelProfile("SeekSecret", SecurityUltraFast, ProfileBalanced, WithStreamIndex())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	data := encryptTestStream(t, client, make([]byte, 3*streamChunkSize))
+	data[len(data)-8] ^= 0x01
+
+	if _, err := client.NewSeekableReader(bytes.NewReader(data)); err == nil {
+		t.Error("NewSeekableReader should fail with a tampered index, but did not")
+	}
+	if err := client.DecryptStream(io.Discard, bytes.NewReader(data)); err == nil {
+		t.Error("DecryptStream should fail with a tampered index, but did not")
+	}
+}
+
+func TestDecryptStreamFrom(t *testing.T) {
+	for _, withIndex := range []bool{true, false} {
+		var opts []Option
+		if withIndex {
+			opts = append(opts, WithStreamIndex())
+		}
+		client, err := NewWithLevelProfile("ResumeSecret", SecurityUltraFast, ProfileBalanced, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		plaintext := make([]byte, 4*streamChunkSize+1000)
+		if _, err := rand.Read(plaintext); err != nil {
+			t.Fatalf("rand.Read failed: %v", err)
+		}
+		encrypted := encryptTestStream(t, client, plaintext)
+
+		var tail bytes.Buffer
+		if err := client.DecryptStreamFrom(&tail, bytes.NewReader(encrypted), 2); err != nil {
+			t.Fatalf("index=%v: DecryptStreamFrom failed: %v", withIndex, err)
+		}
+		if !bytes.Equal(tail.Bytes(), plaintext[2*streamChunkSize:]) {
+			t.Errorf("index=%v: resumed plaintext does not match the tail (%d bytes)", withIndex, tail.Len())
+		}
+
+		if err := client.DecryptStreamFrom(io.Discard, bytes.NewReader(encrypted), 5); !errors.Is(err, ErrInvalidStream) {
+			t.Errorf("index=%v: expected ErrInvalidStream past the last chunk, got %v", withIndex, err)
+		}
+	}
+}
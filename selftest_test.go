@@ -0,0 +1,20 @@
+package cryptio
+
+import "testing"
+
+func TestSelfTest(t *testing.T) {
+	if err := SelfTest(); err != nil {
+		t.Fatalf("SelfTest failed: %v", err)
+	}
+
+	corruptGCM := selfTestVectors
+	corruptGCM.gcmSealed = "0" + corruptGCM.gcmSealed[1:]
+	if err := selfTest(corruptGCM); err == nil {
+		t.Error("selfTest should fail with a corrupted AES-GCM vector, but did not")
+	}
+	corruptArgon2 := selfTestVectors
+	corruptArgon2.argon2id = corruptArgon2.argon2id[:len(corruptArgon2.argon2id)-1] + "0"
+	if err := selfTest(corruptArgon2); err == nil {
+		t.Error("selfTest should fail with a corrupted Argon2id vector, but did not")
+	}
+}
@@ -0,0 +1,45 @@
+package cryptio
+
+import (
+	"crypto/ed25519"
+	"fmt"
+)
+
+// signContext prefixes what SignAndEncrypt signs, so that its signatures cannot
+// be passed off as signatures of the bare plaintext by another protocol.
+const signContext = "cryptio signed plaintext\x00"
+
+// SignAndEncrypt signs plaintext with signer and encrypts the Ed25519 signature
+// (64 bytes) followed by the plaintext. Unlike the AEAD tag, which anyone with
+// the passphrase can produce, the signature proves which key holder wrote the
+// data, e.g. for audit trails. DecryptRaw returns the signature and plaintext
+// together; use DecryptAndVerify.
+func (c *Client) SignAndEncrypt(plaintext []byte, signer ed25519.PrivateKey) ([]byte, error) {
+	if len(signer) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("%w: Ed25519 private key must be %d bytes, got %d", ErrInvalidParams, ed25519.PrivateKeySize, len(signer))
+	}
+	payload := make([]byte, ed25519.SignatureSize, ed25519.SignatureSize+len(plaintext))
+	copy(payload, ed25519.Sign(signer, append([]byte(signContext), plaintext...)))
+	return c.EncryptRaw(append(payload, plaintext...))
+}
+
+// DecryptAndVerify decrypts data produced by SignAndEncrypt and returns the
+// plaintext only if its signature verifies with pub, failing with
+// ErrSignatureInvalid otherwise.
+func (c *Client) DecryptAndVerify(data []byte, pub ed25519.PublicKey) ([]byte, error) {
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: Ed25519 public key must be %d bytes, got %d", ErrInvalidParams, ed25519.PublicKeySize, len(pub))
+	}
+	payload, err := c.DecryptRaw(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) < ed25519.SignatureSize {
+		return nil, fmt.Errorf("%w: no signature", ErrSignatureInvalid)
+	}
+	sig, plaintext := payload[:ed25519.SignatureSize], payload[ed25519.SignatureSize:]
+	if !ed25519.Verify(pub, append([]byte(signContext), plaintext...), sig) {
+		return nil, ErrSignatureInvalid
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,47 @@
+package cryptio
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestSignAndEncrypt(t *testing.T) {
+	client, err := NewWithLevelProfile("AuditSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	data, err := client.SignAndEncrypt([]byte("user 7 approved transfer 42"), priv)
+	if err != nil {
+		t.Fatalf("SignAndEncrypt failed: %v", err)
+	}
+	plaintext, err := client.DecryptAndVerify(data, pub)
+	if err != nil {
+		t.Fatalf("DecryptAndVerify failed: %v", err)
+	}
+	if string(plaintext) != "user 7 approved transfer 42" {
+		t.Errorf("Unexpected plaintext %q", plaintext)
+	}
+
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	if _, err := client.DecryptAndVerify(data, otherPub); !errors.Is(err, ErrSignatureInvalid) {
+		t.Errorf("Expected ErrSignatureInvalid with the wrong public key, got %v", err)
+	}
+
+	// Anyone with the passphrase can encrypt, but not sign in someone else's name.
+	forged, err := client.EncryptRaw(append(make([]byte, ed25519.SignatureSize), "user 7 approved transfer 43"...))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+	if _, err := client.DecryptAndVerify(forged, pub); !errors.Is(err, ErrSignatureInvalid) {
+		t.Errorf("Expected ErrSignatureInvalid for a forged payload, got %v", err)
+	}
+}
@@ -0,0 +1,560 @@
+package cryptio
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Stream format:
+//
+//	magic "CRYS" (4) | version (1) | flags (1)
+//	| Argon2 time cost (uint32 BE) | memory in KiB (uint32 BE) | threads (1), with streamFlagParams
+//	| salt size (1), with streamFlagSaltSize
+//	| salt (SaltSize) | nonce prefix (7)
+//	| chunk size (uint32 BE, with streamFlagChunkSize)
+//	| total plaintext length (uint64 BE, with streamFlagLength)
+//	then one or more frames: length (uint32 BE, high bit set on the final frame) | sealed chunk
+//	then, with streamFlagIndex, the sealed chunk index | index length (uint32 BE)
+//
+// Each chunk holds up to the chunk size of plaintext, streamChunkSize unless the
+// header records another (see WithChunkSize), and is sealed with
+// nonce = prefix || counter (uint32 BE) || final flag, and the stream header as
+// additional data. Reordering, truncation and header tampering are all detected.
+//
+// The index lists the offset of every frame from the start of the stream as
+// uint64 BE values. It is sealed like a chunk, with the chunk count as counter
+// and 2 as final flag, so it can be trusted to seek without scanning the frames.
+//
+// The total length is recorded when the encryptor knows the size upfront
+// (EncryptReaderAt). Being part of the header, it is authenticated with every chunk.
+//
+// The Argon2 costs are recorded unless the encryptor uses WithImplicitParams, so
+// that streams decrypt whatever the costs of the decrypting client. Streams
+// without them are decrypted with the client's costs, and streams without a salt
+// size with the client's salt size.
+
+const (
+	streamVersion    = 1
+	streamChunkSize  = 64 * 1024 // default plaintext bytes per chunk
+	minChunkSize     = 1 << 10   // bounds of WithChunkSize and of the chunk size recorded in a header
+	maxChunkSize     = 16 << 20
+	streamFinalFlag  = 1 << 31   // set in the frame length of the last chunk
+	streamNonceExtra = 5         // counter (4) + final flag (1) appended to the nonce prefix
+	streamMaxChunks  = 1<<32 - 1 // the index nonce needs the chunk count to fit in the counter
+
+	streamFlagIndex     = 1 << 0 // a chunk index follows the final frame
+	streamFlagLength    = 1 << 1 // the header records the total plaintext length
+	streamFlagChunkSize = 1 << 2 // the header records a chunk size other than streamChunkSize
+	streamFlagParams    = 1 << 3 // the header records the Argon2 costs
+	streamFlagSaltSize  = 1 << 4 // the header records the salt size
+	streamFlagsKnown    = streamFlagIndex | streamFlagLength | streamFlagChunkSize | streamFlagParams | streamFlagSaltSize
+
+	streamParamsSize = 4 + 4 + 1 // Argon2 time, memory and threads recorded with streamFlagParams
+
+	nonceKindChunk = 0
+	nonceKindFinal = 1
+	nonceKindIndex = 2
+)
+
+var streamMagic = []byte("CRYS")
+
+// streamCipher seals and opens the chunks of a single stream.
+type streamCipher struct {
+	aead   cipher.AEAD
+	flags  byte
+	header []byte
+	prefix []byte
+	nonce  []byte
+	length int64 // total plaintext length, -1 when not recorded
+
+	chunkSize int    // plaintext bytes per chunk
+	params    Params // key derivation parameters of the stream
+}
+
+// newStreamCipher derives the stream key and builds a streamCipher from a stream
+// header, with the parameters it records and p for the others.
+func (c *Client) newStreamCipher(encoded []byte, p Params) (*streamCipher, error) {
+	flags := encoded[len(streamMagic)+1]
+	rest := encoded[len(streamMagic)+2:]
+	if flags&streamFlagParams != 0 {
+		p.ArgonTime = binary.BigEndian.Uint32(rest)
+		p.ArgonMem = binary.BigEndian.Uint32(rest[4:])
+		p.ArgonThreads = rest[8]
+		rest = rest[streamParamsSize:]
+		if p.ArgonTime < 1 || p.ArgonThreads < 1 || p.ArgonMem > maxHeaderArgonMem || p.ArgonTime > maxHeaderArgonTime {
+			return nil, fmt.Errorf("%w: invalid Argon2 costs", ErrInvalidStream)
+		}
+	}
+	if flags&streamFlagSaltSize != 0 {
+		p.SaltSize = int(rest[0])
+		rest = rest[1:]
+		if p.SaltSize < minSaltSize {
+			return nil, fmt.Errorf("%w: invalid salt size %d", ErrInvalidStream, p.SaltSize)
+		}
+	}
+	salt := rest[:p.SaltSize]
+	prefixEnd := p.SaltSize + gcmNonceSize - streamNonceExtra
+	sc := &streamCipher{
+		flags:     flags,
+		header:    encoded,
+		prefix:    rest[p.SaltSize:prefixEnd],
+		length:    -1,
+		chunkSize: streamChunkSize,
+	}
+	extra := rest[prefixEnd:]
+	if sc.flags&streamFlagChunkSize != 0 {
+		size := binary.BigEndian.Uint32(extra)
+		if size < minChunkSize || size > maxChunkSize {
+			return nil, fmt.Errorf("%w: invalid chunk size %d", ErrInvalidStream, size)
+		}
+		sc.chunkSize = int(size)
+		extra = extra[4:]
+	}
+	if sc.flags&streamFlagLength != 0 {
+		length := binary.BigEndian.Uint64(extra)
+		if length > math.MaxInt64 {
+			return nil, fmt.Errorf("%w: invalid total length", ErrInvalidStream)
+		}
+		sc.length = int64(length)
+	}
+	p.NonceSize = gcmNonceSize
+	aead, err := c.newAEAD(salt, header{params: p, tagSize: gcmTagSize})
+	if err != nil {
+		return nil, err
+	}
+	sc.aead = aead
+	sc.params = p
+	sc.nonce = make([]byte, aead.NonceSize())
+	return sc, nil
+}
+
+// streamHeaderSize returns the encoded size of a stream header with flags and a
+// salt of saltSize bytes.
+func streamHeaderSize(flags byte, saltSize int) int {
+	n := len(streamMagic) + 2 + saltSize + gcmNonceSize - streamNonceExtra
+	if flags&streamFlagParams != 0 {
+		n += streamParamsSize
+	}
+	if flags&streamFlagSaltSize != 0 {
+		n++
+	}
+	if flags&streamFlagChunkSize != 0 {
+		n += 4
+	}
+	if flags&streamFlagLength != 0 {
+		n += 8
+	}
+	return n
+}
+
+// newStreamHeader generates the header of a new stream with a random salt and nonce prefix.
+// length is recorded when flags has streamFlagLength, the Argon2 costs of p
+// unless c has WithImplicitParams, the salt size of p always, and the chunk size
+// of c when it is not the default.
+func (c *Client) newStreamHeader(flags byte, p Params, length uint64) ([]byte, error) {
+	flags |= streamFlagSaltSize
+	if !c.implicitParams {
+		flags |= streamFlagParams
+	}
+	if c.encryptChunkSize() != streamChunkSize {
+		flags |= streamFlagChunkSize
+	}
+	header := make([]byte, 0, streamHeaderSize(flags, p.SaltSize))
+	header = append(header, streamMagic...)
+	header = append(header, streamVersion, flags)
+	if flags&streamFlagParams != 0 {
+		header = appendCosts(header, p)
+	}
+	header = append(header, byte(p.SaltSize))
+	start := len(header)
+	header = header[:start+p.SaltSize+gcmNonceSize-streamNonceExtra]
+	salt := header[start : start+p.SaltSize]
+	if err := c.newSalt(salt); err != nil {
+		return nil, err
+	}
+	if err := c.readFresh(c.rand, header[start+p.SaltSize:]); err != nil {
+		return nil, err
+	}
+	if flags&streamFlagChunkSize != 0 {
+		header = binary.BigEndian.AppendUint32(header, uint32(c.encryptChunkSize())) //nolint:gosec // bounded by maxChunkSize
+	}
+	if flags&streamFlagLength != 0 {
+		header = binary.BigEndian.AppendUint64(header, length)
+	}
+	return header, nil
+}
+
+// encryptChunkSize returns the plaintext bytes per chunk of the streams c encrypts.
+func (c *Client) encryptChunkSize() int {
+	if c.chunkSize == 0 {
+		return streamChunkSize
+	}
+	return c.chunkSize
+}
+
+// readStreamHeader reads and checks the header at the start of src. Recorded
+// Argon2 costs must meet the WithRejectBelowPolicy floor.
+func (c *Client) readStreamHeader(src io.Reader) (*streamCipher, error) {
+	p := c.currentParams()
+	header := make([]byte, len(streamMagic)+2)
+	if _, err := io.ReadFull(src, header); err != nil {
+		return nil, fmt.Errorf("%w: short header", ErrInvalidStream)
+	}
+	if string(header[:len(streamMagic)]) != string(streamMagic) {
+		return nil, fmt.Errorf("%w: bad magic", ErrInvalidStream)
+	}
+	if header[len(streamMagic)] != streamVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidStream, header[len(streamMagic)])
+	}
+	flags := header[len(streamMagic)+1]
+	if flags&^streamFlagsKnown != 0 {
+		return nil, fmt.Errorf("%w: unknown flags %#x", ErrInvalidStream, flags)
+	}
+	saltSize := p.SaltSize
+	if flags&streamFlagSaltSize != 0 {
+		// The salt size comes first, after the Argon2 costs if any.
+		n := 1
+		if flags&streamFlagParams != 0 {
+			n += streamParamsSize
+		}
+		header = append(header, make([]byte, n)...)
+		if _, err := io.ReadFull(src, header[len(header)-n:]); err != nil {
+			return nil, fmt.Errorf("%w: short header", ErrInvalidStream)
+		}
+		saltSize = int(header[len(header)-1])
+	}
+	start := len(header)
+	header = append(header, make([]byte, streamHeaderSize(flags, saltSize)-start)...)
+	if _, err := io.ReadFull(src, header[start:]); err != nil {
+		return nil, fmt.Errorf("%w: short header", ErrInvalidStream)
+	}
+	sc, err := c.newStreamCipher(header, p)
+	if err != nil {
+		return nil, err
+	}
+	if flags&streamFlagParams != 0 {
+		if err := c.checkPolicy(sc.params); err != nil {
+			return nil, err
+		}
+	}
+	return sc, nil
+}
+
+// chunkNonce builds the nonce of the chunk at position counter.
+func (s *streamCipher) chunkNonce(counter uint32, kind byte) []byte {
+	n := copy(s.nonce, s.prefix)
+	binary.BigEndian.PutUint32(s.nonce[n:], counter)
+	s.nonce[n+4] = kind
+	return s.nonce
+}
+
+func finalKind(final bool) byte {
+	if final {
+		return nonceKindFinal
+	}
+	return nonceKindChunk
+}
+
+// maxSealedChunk is the largest valid sealed chunk, used to bound reads of untrusted frames.
+func (s *streamCipher) maxSealedChunk() int {
+	return s.chunkSize + s.aead.Overhead()
+}
+
+// sealFrame appends the frame for chunk to dst.
+func (s *streamCipher) sealFrame(dst, chunk []byte, counter uint32, final bool) []byte {
+	length := uint32(len(chunk) + s.aead.Overhead()) //nolint:gosec // bounded by maxChunkSize
+	if final {
+		length |= streamFinalFlag
+	}
+	dst = binary.BigEndian.AppendUint32(dst, length)
+	return s.aead.Seal(dst, s.chunkNonce(counter, finalKind(final)), chunk, s.header)
+}
+
+// openChunk authenticates and decrypts a sealed chunk, appending the plaintext to dst.
+func (s *streamCipher) openChunk(dst, sealed []byte, counter uint32, final bool) ([]byte, error) {
+	plaintext, err := s.aead.Open(dst, s.chunkNonce(counter, finalKind(final)), sealed, s.header)
+	if err != nil {
+		return nil, fmt.Errorf("chunk %d: %w", counter, err)
+	}
+	return plaintext, nil
+}
+
+// parseFrameLength splits a frame length prefix into its sealed size and final flag.
+func (s *streamCipher) parseFrameLength(prefix []byte, counter uint32) (int, bool, error) {
+	length := binary.BigEndian.Uint32(prefix)
+	size := int(length &^ streamFinalFlag)
+	if size < s.aead.Overhead() || size > s.maxSealedChunk() {
+		return 0, false, fmt.Errorf("%w: chunk %d has invalid length %d", ErrInvalidStream, counter, size)
+	}
+	return size, length&streamFinalFlag != 0, nil
+}
+
+// sealIndex appends the sealed index of frame offsets, followed by its length, to dst.
+func (s *streamCipher) sealIndex(dst []byte, offsets []uint64) []byte {
+	plain := make([]byte, 0, 8*len(offsets))
+	for _, off := range offsets {
+		plain = binary.BigEndian.AppendUint64(plain, off)
+	}
+	start := len(dst)
+	dst = s.aead.Seal(dst, s.chunkNonce(uint32(len(offsets)), nonceKindIndex), plain, s.header) //nolint:gosec // at most streamMaxChunks
+	return binary.BigEndian.AppendUint32(dst, uint32(len(dst)-start))                           //nolint:gosec // bounded by the chunk count
+}
+
+// openIndex authenticates a sealed index and returns the frame offsets it lists.
+func (s *streamCipher) openIndex(sealed []byte) ([]uint64, error) {
+	if len(sealed) < s.aead.Overhead()+8 || (len(sealed)-s.aead.Overhead())%8 != 0 {
+		return nil, fmt.Errorf("%w: invalid index length", ErrInvalidStream)
+	}
+	count := (len(sealed) - s.aead.Overhead()) / 8
+	plain, err := s.aead.Open(nil, s.chunkNonce(uint32(count), nonceKindIndex), sealed, s.header) //nolint:gosec // bounded by len(sealed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: index: %w", ErrInvalidStream, err)
+	}
+	offsets := make([]uint64, count)
+	for i := range offsets {
+		offsets[i] = binary.BigEndian.Uint64(plain[8*i:])
+	}
+	return offsets, nil
+}
+
+// checkIndex reads the index trailing the final frame and checks it matches the frames read.
+func (s *streamCipher) checkIndex(src io.Reader, offsets []uint64) error {
+	sealed := make([]byte, 8*len(offsets)+s.aead.Overhead()+4)
+	if _, err := io.ReadFull(src, sealed); err != nil {
+		return fmt.Errorf("%w: truncated index", ErrInvalidStream)
+	}
+	if int(binary.BigEndian.Uint32(sealed[len(sealed)-4:])) != len(sealed)-4 {
+		return fmt.Errorf("%w: invalid index length", ErrInvalidStream)
+	}
+	indexed, err := s.openIndex(sealed[:len(sealed)-4])
+	if err != nil {
+		return err
+	}
+	for i := range offsets {
+		if indexed[i] != offsets[i] {
+			return fmt.Errorf("%w: index does not match chunk %d", ErrInvalidStream, i)
+		}
+	}
+	return nil
+}
+
+// truncated reports a stream whose plaintext does not add up to the recorded total length.
+func (s *streamCipher) truncated(written int64) error {
+	return fmt.Errorf("%w: %w: decrypted %d of %d bytes", ErrInvalidStream, ErrTruncatedStream, written, s.length)
+}
+
+// EncryptStream encrypts everything read from src and writes the encrypted stream to dst.
+// The key is derived once per stream, so arbitrarily large inputs only pay for one Argon2 run.
+// With WithStreamIndex, a sealed index of chunk offsets is appended for SeekableReader.
+func (c *Client) EncryptStream(dst io.Writer, src io.Reader) error {
+	return c.EncryptStreamContext(context.Background(), dst, src)
+}
+
+// EncryptStreamContext is EncryptStream stopping with ctx.Err() when ctx is done,
+// checked before each chunk. dst is then left with a truncated stream, which
+// DecryptStream rejects.
+func (c *Client) EncryptStreamContext(ctx context.Context, dst io.Writer, src io.Reader) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var flags byte
+	if c.streamIndex {
+		flags |= streamFlagIndex
+	}
+	p := c.currentParams()
+	header, err := c.newStreamHeader(flags, p, 0)
+	if err != nil {
+		return err
+	}
+	sc, err := c.newStreamCipher(header, p)
+	if err != nil {
+		return err
+	}
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+
+	var offsets []uint64
+	offset := uint64(len(header))
+	br := bufio.NewReaderSize(src, sc.chunkSize)
+	chunk := make([]byte, sc.chunkSize)
+	frame := make([]byte, 0, 4+sc.maxSealedChunk())
+	for counter := uint32(0); ; counter++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, err := io.ReadFull(br, chunk)
+		final := false
+		switch {
+		case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF):
+			final = true
+		case err != nil:
+			return err
+		default:
+			if _, err := br.Peek(1); errors.Is(err, io.EOF) {
+				final = true
+			} else if err != nil {
+				return err
+			}
+		}
+		if !final && counter == streamMaxChunks-1 {
+			return fmt.Errorf("%w: too many chunks", ErrInvalidStream)
+		}
+		frame = sc.sealFrame(frame[:0], chunk[:n], counter, final)
+		if _, err := dst.Write(frame); err != nil {
+			return err
+		}
+		if c.streamIndex {
+			offsets = append(offsets, offset)
+			offset += uint64(len(frame))
+		}
+		if final {
+			break
+		}
+	}
+	if c.streamIndex {
+		if _, err := dst.Write(sc.sealIndex(nil, offsets)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecryptStream decrypts a stream produced by EncryptStream and writes the plaintext to dst.
+// Each chunk is authenticated before being written, but dst may already hold the
+// leading chunks when an error is returned for a later one.
+// With WithMaxDecryptedSize, decryption stops with ErrSizeLimitExceeded before
+// writing a chunk that would take the output past the limit.
+func (c *Client) DecryptStream(dst io.Writer, src io.Reader) error {
+	return c.DecryptStreamContext(context.Background(), dst, src)
+}
+
+// DecryptStreamContext is DecryptStream stopping with ctx.Err() when ctx is done,
+// checked before each chunk. dst then holds only the chunks decrypted so far.
+func (c *Client) DecryptStreamContext(ctx context.Context, dst io.Writer, src io.Reader) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	sc, err := c.readStreamHeader(src)
+	if err != nil {
+		return err
+	}
+
+	var offsets []uint64
+	var written int64
+	offset := uint64(len(sc.header))
+	sealed := make([]byte, sc.maxSealedChunk())
+	plain := make([]byte, 0, sc.chunkSize)
+	var lenBuf [4]byte
+	for counter := uint32(0); ; counter++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(src, lenBuf[:]); err != nil {
+			if sc.length >= 0 {
+				return sc.truncated(written)
+			}
+			return fmt.Errorf("%w: truncated before final chunk", ErrInvalidStream)
+		}
+		size, final, err := sc.parseFrameLength(lenBuf[:], counter)
+		if err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(src, sealed[:size]); err != nil {
+			if sc.length >= 0 {
+				return sc.truncated(written)
+			}
+			return fmt.Errorf("%w: chunk %d is truncated", ErrInvalidStream, counter)
+		}
+		plain, err = sc.openChunk(plain[:0], sealed[:size], counter, final)
+		if err != nil {
+			return err
+		}
+		written += int64(len(plain))
+		if c.maxDecrypted > 0 && written > c.maxDecrypted {
+			return fmt.Errorf("%w: more than %d bytes", ErrSizeLimitExceeded, c.maxDecrypted)
+		}
+		if _, err := dst.Write(plain); err != nil {
+			return err
+		}
+		if sc.flags&streamFlagIndex != 0 {
+			offsets = append(offsets, offset)
+			offset += uint64(4 + size)
+		}
+		if final {
+			if sc.length >= 0 && written != sc.length {
+				return sc.truncated(written)
+			}
+			break
+		}
+		if counter == streamMaxChunks-1 {
+			return fmt.Errorf("%w: too many chunks", ErrInvalidStream)
+		}
+	}
+	if sc.flags&streamFlagIndex != 0 {
+		if err := sc.checkIndex(src, offsets); err != nil {
+			return err
+		}
+	}
+	if n, _ := io.ReadFull(src, lenBuf[:1]); n > 0 {
+		return fmt.Errorf("%w: trailing data after final chunk", ErrInvalidStream)
+	}
+	return nil
+}
+
+// verifiedReader decrypts a whole stream before releasing any plaintext.
+type verifiedReader struct {
+	c     *Client
+	src   io.Reader
+	plain *bytes.Reader
+	err   error
+}
+
+// NewDecryptingReader returns a reader of the plaintext of the stream in src that
+// emits nothing until every chunk, the final marker and the index (if any) are
+// authenticated; on failure the first Read returns ErrAuthFailed and no data.
+//
+// Unlike DecryptStream, which releases each chunk as soon as it is verified, the
+// whole plaintext is held in memory and the first byte is only available once
+// the entire stream has been read. Bound it with WithMaxDecryptedSize when src
+// is untrusted.
+func (c *Client) NewDecryptingReader(src io.Reader) io.Reader {
+	return &verifiedReader{c: c, src: src}
+}
+
+func (r *verifiedReader) Read(p []byte) (int, error) {
+	if r.plain == nil && r.err == nil {
+		var buf bytes.Buffer
+		if err := r.c.DecryptStream(&buf, r.src); err != nil {
+			r.err = fmt.Errorf("%w: %w", ErrAuthFailed, err)
+		} else {
+			r.plain = bytes.NewReader(buf.Bytes())
+		}
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+	return r.plain.Read(p)
+}
+
+// RekeyStream decrypts the stream in src with c and encrypts it again into dst
+// with newClient, e.g. to rotate the passphrase of a large archive. Data flows
+// chunk by chunk through a pipe, so memory use does not depend on the stream size.
+// If src turns out invalid, the error is returned and dst is left without a final
+// chunk, so the partial output cannot be mistaken for a complete stream.
+func (c *Client) RekeyStream(dst io.Writer, src io.Reader, newClient *Client) error {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(c.DecryptStream(pw, src))
+	}()
+	err := newClient.EncryptStream(dst, pr)
+	pr.CloseWithError(err) // unblocks DecryptStream if encryption failed first
+	return err
+}
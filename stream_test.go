@@ -0,0 +1,379 @@
+package cryptio
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestEncryptDecryptStream(t *testing.T) {
+	client, err := NewWithLevelProfile("StreamSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	sizes := []int{0, 1, streamChunkSize - 1, streamChunkSize, streamChunkSize + 1, 3*streamChunkSize + 7}
+	for _, size := range sizes {
+		plaintext := make([]byte, size)
+		if _, err := rand.Read(plaintext); err != nil {
+			t.Fatalf("rand.Read failed: %v", err)
+		}
+
+		var encrypted bytes.Buffer
+		if err := client.EncryptStream(&encrypted, bytes.NewReader(plaintext)); err != nil {
+			t.Fatalf("EncryptStream(%d bytes) failed: %v", size, err)
+		}
+
+		var decrypted bytes.Buffer
+		if err := client.DecryptStream(&decrypted, &encrypted); err != nil {
+			t.Fatalf("DecryptStream(%d bytes) failed: %v", size, err)
+		}
+		if !bytes.Equal(plaintext, decrypted.Bytes()) {
+			t.Errorf("Stream of %d bytes did not round-trip", size)
+		}
+	}
+}
+
+func TestDecryptStreamRejectsTampering(t *testing.T) {
+	client, err := NewWithLevelProfile("StreamSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	plaintext := bytes.Repeat([]byte("cryptio"), streamChunkSize/3)
+
+	var encrypted bytes.Buffer
+	if err := client.EncryptStream(&encrypted, bytes.NewReader(plaintext)); err != nil {
+		t.Fatalf("EncryptStream failed: %v", err)
+	}
+	data := encrypted.Bytes()
+	finalFrameStart := len(data) - (4 + len(plaintext)%streamChunkSize + 16)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"flipped byte", func() []byte {
+			d := bytes.Clone(data)
+			d[len(d)-1] ^= 0x01
+			return d
+		}()},
+		{"dropped final chunk", data[:finalFrameStart]},
+		{"trailing data", append(bytes.Clone(data), 0x00)},
+		{"bad magic", append([]byte("XXXX"), data[4:]...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := client.DecryptStream(&out, bytes.NewReader(tt.data)); err == nil {
+				t.Error("DecryptStream should fail, but did not")
+			}
+		})
+	}
+
+	var out bytes.Buffer
+	err = client.DecryptStream(&out, bytes.NewReader(data[:finalFrameStart]))
+	if !errors.Is(err, ErrInvalidStream) {
+		t.Errorf("Expected ErrInvalidStream for a truncated stream, got %v", err)
+	}
+}
+
+func TestDecryptStreamMaxSize(t *testing.T) {
+	encrypter, err := NewWithLevelProfile("LimitSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	plaintext := make([]byte, 10*streamChunkSize)
+	var encrypted bytes.Buffer
+	if err := encrypter.EncryptStream(&encrypted, bytes.NewReader(plaintext)); err != nil {
+		t.Fatalf("EncryptStream failed: %v", err)
+	}
+	data := encrypted.Bytes()
+
+	limit := int64(2*streamChunkSize + 100)
+	limited, err := NewWithLevelProfile("LimitSecret", SecurityUltraFast, ProfileBalanced, WithMaxDecryptedSize(limit))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	var out bytes.Buffer
+	err = limited.DecryptStream(&out, bytes.NewReader(data))
+	if !errors.Is(err, ErrSizeLimitExceeded) {
+		t.Fatalf("Expected ErrSizeLimitExceeded, got %v", err)
+	}
+	if int64(out.Len()) > limit {
+		t.Errorf("Expected at most %d bytes written, got %d", limit, out.Len())
+	}
+
+	// A limit equal to the plaintext size is not exceeded.
+	exact, err := NewWithLevelProfile("LimitSecret", SecurityUltraFast, ProfileBalanced, WithMaxDecryptedSize(int64(len(plaintext))))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	out.Reset()
+	if err := exact.DecryptStream(&out, bytes.NewReader(data)); err != nil {
+		t.Errorf("DecryptStream at the exact limit failed: %v", err)
+	}
+}
+
+func TestNewDecryptingReader(t *testing.T) {
+	client, err := NewWithLevelProfile("ReaderSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	plaintext := bytes.Repeat([]byte("verified before release "), streamChunkSize/8)
+	var encrypted bytes.Buffer
+	if err := client.EncryptStream(&encrypted, bytes.NewReader(plaintext)); err != nil {
+		t.Fatalf("EncryptStream failed: %v", err)
+	}
+
+	decrypted, err := io.ReadAll(client.NewDecryptingReader(bytes.NewReader(encrypted.Bytes())))
+	if err != nil {
+		t.Fatalf("Reading the decrypting reader failed: %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Error("Decrypted data does not match")
+	}
+
+	// Corrupt the tag of the last chunk: the leading chunks are valid but must not be released.
+	tampered := append([]byte{}, encrypted.Bytes()...)
+	tampered[len(tampered)-1] ^= 1
+	got, err := io.ReadAll(client.NewDecryptingReader(bytes.NewReader(tampered)))
+	if !errors.Is(err, ErrAuthFailed) {
+		t.Errorf("Expected ErrAuthFailed, got %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Expected no plaintext on failure, got %d bytes", len(got))
+	}
+}
+
+func TestRekeyStream(t *testing.T) {
+	oldClient, err := NewWithLevelProfile("OldSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	newClient, err := NewWithLevelProfile("NewSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	plaintext := make([]byte, 40*streamChunkSize+321)
+	if _, err := rand.Read(plaintext); err != nil {
+		t.Fatalf("rand.Read failed: %v", err)
+	}
+	var encrypted, rekeyed bytes.Buffer
+	if err := oldClient.EncryptStream(&encrypted, bytes.NewReader(plaintext)); err != nil {
+		t.Fatalf("EncryptStream failed: %v", err)
+	}
+
+	if err := oldClient.RekeyStream(&rekeyed, bytes.NewReader(encrypted.Bytes()), newClient); err != nil {
+		t.Fatalf("RekeyStream failed: %v", err)
+	}
+	var decrypted bytes.Buffer
+	if err := newClient.DecryptStream(&decrypted, bytes.NewReader(rekeyed.Bytes())); err != nil {
+		t.Fatalf("DecryptStream with the new client failed: %v", err)
+	}
+	if !bytes.Equal(decrypted.Bytes(), plaintext) {
+		t.Error("Rekeyed stream does not decrypt to the original data")
+	}
+	if err := oldClient.DecryptStream(io.Discard, bytes.NewReader(rekeyed.Bytes())); err == nil {
+		t.Error("The old client should not decrypt the rekeyed stream, but did")
+	}
+
+	// A truncated source fails and leaves no valid output behind.
+	rekeyed.Reset()
+	truncated := encrypted.Bytes()[:encrypted.Len()/2]
+	if err := oldClient.RekeyStream(&rekeyed, bytes.NewReader(truncated), newClient); err == nil {
+		t.Error("RekeyStream of a truncated stream should fail, but did not")
+	}
+	if err := newClient.DecryptStream(io.Discard, bytes.NewReader(rekeyed.Bytes())); err == nil {
+		t.Error("Partial rekeyed output should not decrypt, but did")
+	}
+}
+
+// cancelWriter cancels its context on the first write.
+type cancelWriter struct {
+	bytes.Buffer
+	cancel context.CancelFunc
+}
+
+func (w *cancelWriter) Write(p []byte) (int, error) {
+	w.cancel()
+	return w.Buffer.Write(p)
+}
+
+// endlessReader yields 'x' forever.
+type endlessReader struct{}
+
+func (endlessReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 'x'
+	}
+	return len(p), nil
+}
+
+func TestStreamContextCancel(t *testing.T) {
+	client, err := NewWithLevelProfile("CancelSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// An endless source only ends through cancellation.
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- client.EncryptStreamContext(ctx, &cancelWriter{cancel: cancel}, endlessReader{})
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("EncryptStreamContext did not return after cancellation")
+	}
+
+	encrypted := encryptTestStream(t, client, bytes.Repeat([]byte("y"), 3*streamChunkSize))
+	ctx, cancel = context.WithCancel(context.Background())
+	out := &cancelWriter{cancel: cancel}
+	err = client.DecryptStreamContext(ctx, out, bytes.NewReader(encrypted))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if out.Len() != streamChunkSize {
+		t.Errorf("Expected only the first chunk to be written, got %d bytes", out.Len())
+	}
+}
+
+func TestStreamChunkSize(t *testing.T) {
+	decryptor, err := NewWithLevelProfile("StreamSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	headerLen := streamHeaderSize(streamFlagParams|streamFlagSaltSize, decryptor.params.SaltSize)
+
+	for _, chunkSize := range []int{4 << 10, 1 << 20} {
+		client, err := NewWithLevelProfile("StreamSecret", SecurityUltraFast, ProfileBalanced, WithChunkSize(chunkSize))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		plaintext := make([]byte, 3*chunkSize+7)
+		if _, err := rand.Read(plaintext); err != nil {
+			t.Fatalf("rand.Read failed: %v", err)
+		}
+		var encrypted bytes.Buffer
+		if err := client.EncryptStream(&encrypted, bytes.NewReader(plaintext)); err != nil {
+			t.Fatalf("EncryptStream with %d-byte chunks failed: %v", chunkSize, err)
+		}
+		data := encrypted.Bytes()
+
+		// The header records the chunk size, and the first frame holds a full chunk.
+		if got := int(binary.BigEndian.Uint32(data[headerLen:])); got != chunkSize {
+			t.Errorf("Header records a %d-byte chunk size, want %d", got, chunkSize)
+		}
+		if got := int(binary.BigEndian.Uint32(data[headerLen+4:])); got != chunkSize+gcmTagSize {
+			t.Errorf("First frame is %d bytes, want %d", got, chunkSize+gcmTagSize)
+		}
+
+		// A client with the default chunk size frames the stream from its header.
+		var decrypted bytes.Buffer
+		if err := decryptor.DecryptStream(&decrypted, bytes.NewReader(data)); err != nil {
+			t.Fatalf("DecryptStream of %d-byte chunks failed: %v", chunkSize, err)
+		}
+		if !bytes.Equal(plaintext, decrypted.Bytes()) {
+			t.Errorf("Stream of %d-byte chunks did not round-trip", chunkSize)
+		}
+		r, err := decryptor.NewSeekableReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("NewSeekableReader failed: %v", err)
+		}
+		if _, err := r.Seek(int64(2*chunkSize-3), io.SeekStart); err != nil {
+			t.Fatalf("Seek failed: %v", err)
+		}
+		buf := make([]byte, 10)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			t.Fatalf("Read across chunks failed: %v", err)
+		}
+		if !bytes.Equal(buf, plaintext[2*chunkSize-3:2*chunkSize+7]) {
+			t.Errorf("SeekableReader with %d-byte chunks read the wrong bytes", chunkSize)
+		}
+
+		// The chunk size is authenticated with every chunk.
+		tampered := bytes.Clone(data)
+		binary.BigEndian.PutUint32(tampered[headerLen:], uint32(2*chunkSize))
+		if err := decryptor.DecryptStream(io.Discard, bytes.NewReader(tampered)); err == nil {
+			t.Errorf("DecryptStream accepted a tampered chunk size")
+		}
+	}
+
+	// Streams with the default chunk size do not record it.
+	var encrypted bytes.Buffer
+	if err := decryptor.EncryptStream(&encrypted, bytes.NewReader([]byte("default"))); err != nil {
+		t.Fatalf("EncryptStream failed: %v", err)
+	}
+	if flags := encrypted.Bytes()[len(streamMagic)+1]; flags&streamFlagChunkSize != 0 {
+		t.Errorf("Default stream has the chunk size flag set")
+	}
+
+	for _, n := range []int{0, minChunkSize - 1, maxChunkSize + 1} {
+		if _, err := NewWithLevelProfile("StreamSecret", SecurityUltraFast, ProfileBalanced, WithChunkSize(n)); !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("WithChunkSize(%d): expected ErrInvalidParams, got %v", n, err)
+		}
+	}
+}
+
+func TestStreamRecordsArgon2Costs(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	client, err := NewWithLevelProfile("StreamSecret", SecurityUltraFast, ProfileBalanced, WithAutoThreads())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	other, err := NewWithLevelProfile("StreamSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if client.params.ArgonThreads == other.params.ArgonThreads {
+		t.Fatalf("Expected WithAutoThreads to change the thread count, got %d", client.params.ArgonThreads)
+	}
+	plaintext := bytes.Repeat([]byte("threads "), streamChunkSize/4)
+
+	// Each client decrypts the streams of the other.
+	for name, pair := range map[string][2]*Client{"auto to default": {client, other}, "default to auto": {other, client}} {
+		var encrypted, decrypted bytes.Buffer
+		if err := pair[0].EncryptStream(&encrypted, bytes.NewReader(plaintext)); err != nil {
+			t.Fatalf("%s: EncryptStream failed: %v", name, err)
+		}
+		if err := pair[1].DecryptStream(&decrypted, &encrypted); err != nil {
+			t.Fatalf("%s: DecryptStream failed: %v", name, err)
+		}
+		if !bytes.Equal(plaintext, decrypted.Bytes()) {
+			t.Errorf("%s: stream did not round-trip", name)
+		}
+	}
+
+	var encrypted bytes.Buffer
+	if err := client.EncryptStream(&encrypted, bytes.NewReader(plaintext)); err != nil {
+		t.Fatalf("EncryptStream failed: %v", err)
+	}
+	threads := len(streamMagic) + 2 + 8
+	tampered := bytes.Clone(encrypted.Bytes())
+	tampered[threads]++
+	if err := other.DecryptStream(io.Discard, bytes.NewReader(tampered)); err == nil {
+		t.Error("DecryptStream accepted a tampered thread count")
+	}
+	tampered[threads] = 0
+	if err := other.DecryptStream(io.Discard, bytes.NewReader(tampered)); !errors.Is(err, ErrInvalidStream) {
+		t.Errorf("Expected ErrInvalidStream for zero threads, got %v", err)
+	}
+
+	strict, err := NewWithLevelProfile("StreamSecret", SecurityUltraFast, ProfileBalanced, WithRejectBelowPolicy(Params{ArgonTime: 3}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := strict.DecryptStream(io.Discard, bytes.NewReader(encrypted.Bytes())); !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("Expected ErrPolicyViolation below the floor, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package cryptio
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Strength is a coarse passphrase strength score.
+type Strength int
+
+const (
+	StrengthWeak   Strength = iota // Guessable, should be rejected by callers
+	StrengthFair                   // Resists casual guessing, not a determined attacker
+	StrengthStrong                 // Suitable as the root secret of a Client
+)
+
+func (s Strength) String() string {
+	switch s {
+	case StrengthWeak:
+		return "Weak"
+	case StrengthFair:
+		return "Fair"
+	case StrengthStrong:
+		return "Strong"
+	default:
+		return "Unknown"
+	}
+}
+
+// Entropy thresholds, in bits, for each Strength score.
+const (
+	fairEntropyBits   = 50
+	strongEntropyBits = 80
+)
+
+// StrengthResult is the outcome of EstimatePassphraseStrength.
+type StrengthResult struct {
+	Entropy float64  // Estimated entropy in bits
+	Score   Strength // Coarse score derived from Entropy
+}
+
+// commonPassphrases are well-known passwords that any dictionary attack tries first.
+var commonPassphrases = map[string]bool{
+	"password": true, "password1": true, "password123": true, "123456": true,
+	"12345678": true, "123456789": true, "qwerty": true, "azerty": true,
+	"letmein": true, "welcome": true, "admin": true, "iloveyou": true,
+	"monkey": true, "dragon": true, "secret": true, "changeme": true,
+	"abc123": true, "111111": true, "passw0rd": true, "cryptio": true,
+}
+
+// EstimatePassphraseStrength returns an advisory estimate of the strength of passphrase.
+// It combines the character pool size with penalties for repeated characters,
+// sequences and well-known passwords, in the spirit of zxcvbn but much simpler.
+// The estimate does not prevent creating a Client with a weak passphrase.
+func EstimatePassphraseStrength(passphrase string) StrengthResult {
+	if commonPassphrases[strings.ToLower(passphrase)] {
+		return StrengthResult{Entropy: math.Log2(float64(len(commonPassphrases))), Score: StrengthWeak}
+	}
+
+	var lower, upper, digit, symbol, other bool
+	var effective float64
+	var prev rune
+	for i, r := range passphrase {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+		// Repeats ("aaaa") and runs ("abcd", "4321") add little to a guesser's work.
+		if i > 0 && (r == prev || r == prev+1 || r == prev-1) {
+			effective += 0.25
+		} else {
+			effective++
+		}
+		prev = r
+	}
+
+	pool := 0
+	for _, set := range []struct {
+		used bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if set.used {
+			pool += set.size
+		}
+	}
+	if pool == 0 {
+		return StrengthResult{Score: StrengthWeak}
+	}
+
+	entropy := effective * math.Log2(float64(pool))
+	score := StrengthWeak
+	switch {
+	case entropy >= strongEntropyBits:
+		score = StrengthStrong
+	case entropy >= fairEntropyBits:
+		score = StrengthFair
+	}
+	return StrengthResult{Entropy: entropy, Score: score}
+}
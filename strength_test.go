@@ -0,0 +1,33 @@
+package cryptio
+
+import "testing"
+
+func TestEstimatePassphraseStrength(t *testing.T) {
+	tests := []struct {
+		passphrase string
+		want       Strength
+	}{
+		{"", StrengthWeak},
+		{"password", StrengthWeak},
+		{"Password123", StrengthWeak},
+		{"aaaaaaaaaaaaaaaa", StrengthWeak},
+		{"abcdefghijklmnop", StrengthWeak},
+		{"Tr0ub4dor&3", StrengthFair},
+		{"7dnMFD$#s!grac?4pmCoG8b&Simc8@Ytdh4B&mHb", StrengthStrong},
+		{"bFP4o?BT8B$ki5yCoT#q", StrengthStrong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.passphrase, func(t *testing.T) {
+			got := EstimatePassphraseStrength(tt.passphrase)
+			if got.Score != tt.want {
+				t.Errorf("Expected %s, got %s (%.1f bits)", tt.want, got.Score, got.Entropy)
+			}
+		})
+	}
+
+	weak := EstimatePassphraseStrength("password")
+	strong := EstimatePassphraseStrength("5RfMtsRXP4TCcEmYCfM3abj#A")
+	if weak.Entropy >= strong.Entropy {
+		t.Errorf("Expected weak entropy %.1f below strong entropy %.1f", weak.Entropy, strong.Entropy)
+	}
+}
@@ -0,0 +1,73 @@
+package cryptio
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// readTerminalPassword reads a line from the terminal on standard input without
+// echoing it. Tests replace it.
+var readTerminalPassword = func() ([]byte, error) {
+	return term.ReadPassword(int(os.Stdin.Fd())) //nolint:gosec // file descriptors fit in an int
+}
+
+// NewFromTerminal creates a client like NewWithLevelProfile, reading the
+// passphrase from the terminal with echo disabled after writing prompt to
+// standard error. The buffer read is zeroed once copied into the client.
+func NewFromTerminal(prompt string, level SecurityLevel, profile Argon2Profile, opts ...Option) (*Client, error) {
+	return newFromPrompt(readTerminalPassword, os.Stderr, prompt, false, level, profile, opts...)
+}
+
+// NewFromTerminalConfirmed is NewFromTerminal asking for the passphrase twice,
+// failing with ErrPassphraseMismatch unless both match, for tools about to
+// encrypt with a passphrase that a typo would make unrecoverable.
+func NewFromTerminalConfirmed(prompt string, level SecurityLevel, profile Argon2Profile, opts ...Option) (*Client, error) {
+	return newFromPrompt(readTerminalPassword, os.Stderr, prompt, true, level, profile, opts...)
+}
+
+// newFromPrompt reads the passphrase with read after writing prompt to w, and
+// once more to confirm it if confirm is set. The passphrase never becomes a
+// string, which could not be zeroed.
+func newFromPrompt(read func() ([]byte, error), w io.Writer, prompt string, confirm bool, level SecurityLevel, profile Argon2Profile, opts ...Option) (*Client, error) {
+	passphrase, err := promptPassphrase(read, w, prompt)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(passphrase)
+	if confirm {
+		again, err := promptPassphrase(read, w, "Retype to confirm: ")
+		if err != nil {
+			return nil, err
+		}
+		defer clear(again)
+		if subtle.ConstantTimeCompare(passphrase, again) != 1 {
+			return nil, ErrPassphraseMismatch
+		}
+	}
+	return NewWithLevelProfile("", level, profile, append(opts, func(c *Client) error {
+		c.passphrase = bytes.Clone(passphrase)
+		return nil
+	})...)
+}
+
+// promptPassphrase writes prompt to w and reads one passphrase with read,
+// ending the line the terminal did not echo.
+func promptPassphrase(read func() ([]byte, error), w io.Writer, prompt string) ([]byte, error) {
+	if _, err := io.WriteString(w, prompt); err != nil {
+		return nil, err
+	}
+	passphrase, err := read()
+	if _, werr := io.WriteString(w, "\n"); err == nil {
+		err = werr
+	}
+	if err != nil {
+		clear(passphrase)
+		return nil, fmt.Errorf("read passphrase: %w", err)
+	}
+	return passphrase, nil
+}
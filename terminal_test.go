@@ -0,0 +1,67 @@
+package cryptio
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// scriptedTerminal returns each line in turn, keeping the buffers it handed out.
+type scriptedTerminal struct {
+	lines  []string
+	served [][]byte
+}
+
+func (s *scriptedTerminal) read() ([]byte, error) {
+	if len(s.lines) == 0 {
+		return nil, errors.New("no more input")
+	}
+	buf := []byte(s.lines[0])
+	s.lines = s.lines[1:]
+	s.served = append(s.served, buf)
+	return buf, nil
+}
+
+func TestNewFromPrompt(t *testing.T) {
+	reference, err := NewWithLevelProfile("typed secret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	blob, err := reference.EncryptRaw([]byte("payload"))
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+
+	for _, confirm := range []bool{false, true} {
+		tty := &scriptedTerminal{lines: []string{"typed secret", "typed secret"}}
+		var out bytes.Buffer
+		client, err := newFromPrompt(tty.read, &out, "Passphrase: ", confirm, SecurityUltraFast, ProfileBalanced)
+		if err != nil {
+			t.Fatalf("confirm %v: newFromPrompt failed: %v", confirm, err)
+		}
+		if plaintext, err := client.DecryptRaw(blob); err != nil || string(plaintext) != "payload" {
+			t.Errorf("confirm %v: client does not use the typed passphrase: %q, %v", confirm, plaintext, err)
+		}
+		want := "Passphrase: \n"
+		if confirm {
+			want += "Retype to confirm: \n"
+		}
+		if out.String() != want {
+			t.Errorf("confirm %v: prompts = %q, want %q", confirm, out.String(), want)
+		}
+		for _, buf := range tty.served {
+			if !bytes.Equal(buf, make([]byte, len(buf))) {
+				t.Errorf("confirm %v: passphrase buffer was not wiped: %q", confirm, buf)
+			}
+		}
+	}
+
+	tty := &scriptedTerminal{lines: []string{"typed secret", "typed secert"}}
+	if _, err := newFromPrompt(tty.read, &bytes.Buffer{}, "Passphrase: ", true, SecurityUltraFast, ProfileBalanced); !errors.Is(err, ErrPassphraseMismatch) {
+		t.Errorf("Expected ErrPassphraseMismatch, got %v", err)
+	}
+	tty = &scriptedTerminal{lines: []string{""}}
+	if _, err := newFromPrompt(tty.read, &bytes.Buffer{}, "Passphrase: ", false, SecurityUltraFast, ProfileBalanced); !errors.Is(err, ErrEmptyPassphrase) {
+		t.Errorf("Expected ErrEmptyPassphrase, got %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package cryptio
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// TokenEncryptor encrypts many small messages, such as session tokens, under a
+// namespace key with next to no allocation: the key and the AEAD are set up once,
+// and buffers are reused. Its blobs are ordinary blobs of its client, which
+// decrypts them as usual.
+//
+// It is safe for concurrent use. It keeps the parameters of the client at
+// creation: after Reconfigure, create a new TokenEncryptor.
+type TokenEncryptor struct {
+	c       *Client
+	maxSize int
+	aead    cipher.AEAD
+	prefix  []byte // header and namespace salt, identical for every blob
+	ad      []byte // additional data authenticating the header
+	bufs    sync.Pool
+}
+
+// NewTokenEncryptor returns a TokenEncryptor for plaintexts of at most maxSize
+// bytes, deriving the namespace key now. c must be a namespace client (see
+// NewNamespace) without per-message processing: random padding, compression,
+// checksums, deterministic nonces, per-message subkeys and default additional
+// data are rejected.
+func NewTokenEncryptor(c *Client, maxSize int) (*TokenEncryptor, error) {
+	if maxSize < 1 {
+		return nil, fmt.Errorf("%w: token size %d must be positive", ErrInvalidParams, maxSize)
+	}
+	if c.fixedSalt == nil {
+		return nil, errors.New("TokenEncryptor requires a namespace client, see NewNamespace")
+	}
+	if c.padMax > 0 || c.compression != CompressionNone || c.checksum || c.deterministic || c.subkeys || len(c.defaultAAD) > 0 {
+		return nil, errors.New("TokenEncryptor cannot be used with padding, compression, checksums, deterministic nonces, subkeys or default additional data")
+	}
+	h := c.newHeader()
+	aead, err := c.newAEAD(c.fixedSalt, h)
+	if err != nil {
+		return nil, err
+	}
+	t := &TokenEncryptor{
+		c:       c,
+		maxSize: maxSize,
+		aead:    aead,
+		prefix:  append(writeHeader(nil, h), c.fixedSalt...),
+	}
+	t.ad = headerAAD(t.prefix[:h.size()], h)
+	blobLen := len(t.prefix) + h.params.NonceSize + maxSize + aead.Overhead()
+	t.bufs.New = func() any {
+		buf := make([]byte, 0, blobLen+c.base64Encoding().EncodedLen(blobLen))
+		return &buf
+	}
+	return t, nil
+}
+
+// EncryptRawInto appends the blob encrypting plaintext to dst, like
+// Client.EncryptRawInto. It does not allocate when dst has the capacity.
+func (t *TokenEncryptor) EncryptRawInto(dst, plaintext []byte) ([]byte, error) {
+	if len(plaintext) > t.maxSize {
+		return nil, fmt.Errorf("%w: %d-byte token exceeds the %d-byte maximum", ErrInvalidParams, len(plaintext), t.maxSize)
+	}
+	nonceSize := t.aead.NonceSize()
+	if err := t.c.countNamespaceNonce(nonceSize); err != nil {
+		return nil, err
+	}
+	dst = append(dst, t.prefix...)
+	dst = append(dst, make([]byte, nonceSize)...)
+	nonce := dst[len(dst)-nonceSize:]
+	if err := t.c.readFresh(t.c.rand, nonce); err != nil {
+		return nil, err
+	}
+	if t.c.metrics != nil {
+		t.c.metrics.Encrypted()
+	}
+	return t.aead.Seal(dst, nonce, plaintext, t.ad), nil
+}
+
+// EncryptToken is Client.Encrypt for plaintext, using a pooled buffer so that the
+// returned string is the only allocation.
+func (t *TokenEncryptor) EncryptToken(plaintext []byte) (string, error) {
+	buf := t.bufs.Get().(*[]byte)
+	defer t.bufs.Put(buf)
+	raw, err := t.EncryptRawInto((*buf)[:0], plaintext)
+	if err != nil {
+		return "", err
+	}
+	*buf = t.c.base64Encoding().AppendEncode(raw, raw)
+	return t.c.outputPrefix + string((*buf)[len(raw):]), nil
+}
+
+// DecryptRawInto is Client.DecryptRawInto, without allocating for blobs of this
+// TokenEncryptor. Other blobs are handed to the client.
+func (t *TokenEncryptor) DecryptRawInto(dst, src []byte) ([]byte, error) {
+	nonceSize := t.aead.NonceSize()
+	if len(src) < len(t.prefix)+nonceSize+t.aead.Overhead() || !bytes.Equal(src[:len(t.prefix)], t.prefix) {
+		return t.c.DecryptRawInto(dst, src)
+	}
+	nonce := src[len(t.prefix) : len(t.prefix)+nonceSize]
+	plaintext, err := t.aead.Open(dst[:0], nonce, src[len(t.prefix)+nonceSize:], t.ad)
+	if err != nil {
+		if t.c.metrics != nil {
+			t.c.metrics.AuthFailed()
+		}
+		return nil, fmt.Errorf("%w: %w", ErrDecryptFailed, err)
+	}
+	if t.c.metrics != nil {
+		t.c.metrics.Decrypted()
+	}
+	return plaintext, nil
+}
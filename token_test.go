@@ -0,0 +1,79 @@
+package cryptio
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestTokenEncryptor(t *testing.T) {
+	client, err := NewNamespace("TokenSecret", []byte("session token namespace"), SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	te, err := NewTokenEncryptor(client, 64)
+	if err != nil {
+		t.Fatalf("NewTokenEncryptor failed: %v", err)
+	}
+	token := bytes.Repeat([]byte{0xA5}, 48)
+
+	blob, err := te.EncryptRawInto(nil, token)
+	if err != nil {
+		t.Fatalf("EncryptRawInto failed: %v", err)
+	}
+	if plaintext, err := client.DecryptRaw(blob); err != nil || !bytes.Equal(plaintext, token) {
+		t.Errorf("Client.DecryptRaw of a token blob = %x, %v", plaintext, err)
+	}
+	encoded, err := te.EncryptToken(token)
+	if err != nil {
+		t.Fatalf("EncryptToken failed: %v", err)
+	}
+	if plaintext, err := client.Decrypt(encoded); err != nil || plaintext != string(token) {
+		t.Errorf("Client.Decrypt of a token = %x, %v", plaintext, err)
+	}
+	regular, err := client.EncryptRaw(token)
+	if err != nil {
+		t.Fatalf("EncryptRaw failed: %v", err)
+	}
+	if plaintext, err := te.DecryptRawInto(nil, regular); err != nil || !bytes.Equal(plaintext, token) {
+		t.Errorf("DecryptRawInto of a client blob = %x, %v", plaintext, err)
+	}
+
+	dst := make([]byte, 0, len(blob))
+	out := make([]byte, 0, len(token))
+	if allocs := testing.AllocsPerRun(100, func() {
+		if _, err := te.EncryptRawInto(dst, token); err != nil {
+			t.Fatalf("EncryptRawInto failed: %v", err)
+		}
+		if _, err := te.DecryptRawInto(out, blob); err != nil {
+			t.Fatalf("DecryptRawInto failed: %v", err)
+		}
+	}); allocs != 0 {
+		t.Errorf("Expected no allocation per token, got %v", allocs)
+	}
+
+	blob[len(blob)-1] ^= 1
+	if _, err := te.DecryptRawInto(nil, blob); !errors.Is(err, ErrDecryptFailed) {
+		t.Errorf("Expected ErrDecryptFailed for a tampered token, got %v", err)
+	}
+	if _, err := te.EncryptRawInto(nil, make([]byte, 65)); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Expected ErrInvalidParams for an oversized token, got %v", err)
+	}
+}
+
+func TestNewTokenEncryptorRejects(t *testing.T) {
+	regular, err := NewWithLevelProfile("TokenSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := NewTokenEncryptor(regular, 64); err == nil {
+		t.Error("NewTokenEncryptor without a namespace should fail, but did not")
+	}
+	padded, err := NewNamespace("TokenSecret", []byte("session token namespace"), SecurityUltraFast, ProfileBalanced, WithRandomPadding(0, 16))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := NewTokenEncryptor(padded, 64); err == nil {
+		t.Error("NewTokenEncryptor with padding should fail, but did not")
+	}
+}
@@ -0,0 +1,31 @@
+package cryptio
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// EncryptValue JSON-encodes v and encrypts it with c.Encrypt, for type-safe
+// encrypted configuration fields. Read it back with DecryptValue.
+func EncryptValue[T any](c *Client, v T) (string, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("encode value: %w", err)
+	}
+	return c.Encrypt(string(encoded))
+}
+
+// DecryptValue decrypts a string produced by EncryptValue and decodes it as a T.
+// A value that does not decode as a T returns an error along with the zero T.
+func DecryptValue[T any](c *Client, s string) (T, error) {
+	var v T
+	plaintext, err := c.Decrypt(s)
+	if err != nil {
+		return v, err
+	}
+	if err := json.Unmarshal([]byte(plaintext), &v); err != nil {
+		var zero T
+		return zero, fmt.Errorf("decode value: %w", err)
+	}
+	return v, nil
+}
@@ -0,0 +1,49 @@
+package cryptio
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestEncryptValue(t *testing.T) {
+	client, err := NewWithLevelProfile("ValueSecret", SecurityUltraFast, ProfileBalanced)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	type database struct {
+		Host     string
+		Port     int
+		Password string
+	}
+	want := database{Host: "db.internal", Port: 5432, Password: "hunter2"}
+	encrypted, err := EncryptValue(client, want)
+	if err != nil {
+		t.Fatalf("EncryptValue failed: %v", err)
+	}
+	got, err := DecryptValue[database](client, encrypted)
+	if err != nil {
+		t.Fatalf("DecryptValue failed: %v", err)
+	}
+	if got != want {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+
+	tokens := map[string]string{"github": "ghp_example", "slack": "xoxb-example"}
+	encrypted, err = EncryptValue(client, tokens)
+	if err != nil {
+		t.Fatalf("EncryptValue failed: %v", err)
+	}
+	gotTokens, err := DecryptValue[map[string]string](client, encrypted)
+	if err != nil {
+		t.Fatalf("DecryptValue failed: %v", err)
+	}
+	if !maps.Equal(gotTokens, tokens) {
+		t.Errorf("Expected %v, got %v", tokens, gotTokens)
+	}
+
+	// A map does not decode as an int.
+	if n, err := DecryptValue[int](client, encrypted); err == nil || n != 0 {
+		t.Errorf("DecryptValue into a mismatched type should fail, got %v, %v", n, err)
+	}
+}